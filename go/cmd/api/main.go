@@ -5,10 +5,23 @@ import (
 	"net/http"
 	"os"
 
+	"docqube.de/bookkeeper/pkg/blobstore"
 	"docqube.de/bookkeeper/pkg/config"
 	"docqube.de/bookkeeper/pkg/database"
+	accountHandler "docqube.de/bookkeeper/pkg/services/account/handler"
+	attachmentHandler "docqube.de/bookkeeper/pkg/services/attachment/handler"
+	"docqube.de/bookkeeper/pkg/services/audit"
+	auditHandler "docqube.de/bookkeeper/pkg/services/audit/handler"
+	budgetHandler "docqube.de/bookkeeper/pkg/services/budget/handler"
+	calendarHandler "docqube.de/bookkeeper/pkg/services/calendar/handler"
 	categoryHandler "docqube.de/bookkeeper/pkg/services/category/handler"
+	classifierHandler "docqube.de/bookkeeper/pkg/services/classifier/handler"
+	forecastHandler "docqube.de/bookkeeper/pkg/services/forecast/handler"
 	intervalHandler "docqube.de/bookkeeper/pkg/services/interval/handler"
+	lockHandler "docqube.de/bookkeeper/pkg/services/lock/handler"
+	payeeHandler "docqube.de/bookkeeper/pkg/services/payee/handler"
+	reportHandler "docqube.de/bookkeeper/pkg/services/report/handler"
+	subscriptionHandler "docqube.de/bookkeeper/pkg/services/subscription/handler"
 	transactionHandler "docqube.de/bookkeeper/pkg/services/transaction/handler"
 	"docqube.de/bookkeeper/pkg/utils"
 	"github.com/gin-contrib/gzip"
@@ -50,16 +63,42 @@ func main() {
 	defer db.Close()
 	log.Info("database initialized")
 
+	store, err := blobstore.New(config.BlobStoreConfig)
+	if err != nil {
+		exitCode = 1
+		log.Errorf("initializing blob store: %s", err)
+		return
+	}
+
+	identifier, err := audit.NewIdentifier(config.AuditConfig)
+	if err != nil {
+		exitCode = 1
+		log.Errorf("initializing audit identifier: %s", err)
+		return
+	}
+
 	g := gin.New()
 
 	// router groups
 	v1 := g.Group("/api/v1")
 	v1.Use(gzip.Gzip(gzip.DefaultCompression))
+	v1.Use(auditHandler.Middleware(identifier))
 
 	// register handlers
 	_ = transactionHandler.NewHandler(v1, db)
 	_ = categoryHandler.NewHandler(v1, db)
 	_ = intervalHandler.NewHandler(v1, db)
+	_ = classifierHandler.NewHandler(v1, db)
+	_ = payeeHandler.NewHandler(v1, db)
+	_ = budgetHandler.NewHandler(v1, db)
+	_ = subscriptionHandler.NewHandler(v1, db)
+	_ = forecastHandler.NewHandler(v1, db)
+	_ = reportHandler.NewHandler(v1, db)
+	_ = accountHandler.NewHandler(v1, db)
+	_ = lockHandler.NewHandler(v1, db)
+	_ = calendarHandler.NewHandler(v1, db)
+	_ = attachmentHandler.NewHandler(v1, db, store, config.AttachmentConfig)
+	_ = auditHandler.NewHandler(v1, db)
 
 	g.GET("/healthz/:probe", func(c *gin.Context) {
 		probe := c.Param("probe")
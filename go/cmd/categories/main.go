@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+
+	"docqube.de/bookkeeper/pkg/config"
+	"docqube.de/bookkeeper/pkg/database"
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"docqube.de/bookkeeper/pkg/services/category"
+	log "github.com/sirupsen/logrus"
+)
+
+const usage = `usage:
+  categories export [-format json|yaml] [-output file]
+  categories import [-format json|yaml] [-mode merge|replace] [-dry-run] file`
+
+var (
+	exitCode = 0
+)
+
+func main() {
+	defer func() {
+		os.Exit(exitCode)
+	}()
+
+	if len(os.Args) < 2 {
+		exitCode = 2
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+
+	config, err := config.LoadConfig()
+	if err != nil {
+		exitCode = 1
+		log.Errorf("loading config: %s", err)
+		return
+	}
+
+	db, err := database.InitializeDatabase(config)
+	if err != nil {
+		exitCode = 1
+		log.Errorf("initializing database: %s", err)
+		return
+	}
+	defer db.Close()
+
+	service := category.NewService(db)
+
+	switch os.Args[1] {
+	case "export":
+		err = export(service, os.Args[2:])
+	case "import":
+		err = importSet(service, os.Args[2:])
+	default:
+		exitCode = 2
+		fmt.Fprintln(os.Stderr, usage)
+		return
+	}
+	if err != nil {
+		exitCode = 1
+		log.Errorf("%s: %s", os.Args[1], err)
+	}
+}
+
+func export(service *category.Service, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	rawFormat := flags.String("format", string(category.FormatYAML), "document format (json or yaml)")
+	output := flags.String("output", "", "output file, defaults to stdout")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	format, err := category.ParseFormat(*rawFormat)
+	if err != nil {
+		return err
+	}
+
+	set, err := service.Export()
+	if err != nil {
+		return err
+	}
+
+	var writer io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	return category.EncodeCategorySet(writer, set, format)
+}
+
+func importSet(service *category.Service, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	rawFormat := flags.String("format", string(category.FormatYAML), "document format (json or yaml)")
+	rawMode := flags.String("mode", string(category.ImportModeMerge), "import mode (merge or replace)")
+	dryRun := flags.Bool("dry-run", false, "only print the changes without applying them")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("exactly one input file expected")
+	}
+
+	format, err := category.ParseFormat(*rawFormat)
+	if err != nil {
+		return err
+	}
+
+	mode, err := category.ParseImportMode(*rawMode)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	set, err := category.DecodeCategorySet(file, format)
+	if err != nil {
+		return err
+	}
+
+	var preview *category.ImportPreview
+	if *dryRun {
+		preview, err = service.PreviewImport(set, mode)
+	} else {
+		preview, err = service.Import(cliActor(), set, mode)
+	}
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(preview)
+}
+
+// cliActor returns the user running the command as actor of the recorded changes.
+func cliActor() audit.Actor {
+	actor := audit.Actor{Type: audit.ActorUser}
+	if current, err := user.Current(); err == nil {
+		actor.Name = current.Username
+	}
+	return actor
+}
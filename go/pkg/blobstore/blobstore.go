@@ -0,0 +1,50 @@
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"docqube.de/bookkeeper/pkg/config"
+)
+
+var (
+	ErrBlobNotFound     = errors.New("blob not found")
+	ErrInvalidKey       = errors.New("invalid blob key")
+	ErrUnknownStoreKind = errors.New("unknown blob store kind")
+)
+
+// Store stores binary objects like attachments by a key. Keys are slash separated paths.
+type Store interface {
+	// Put stores the content read from the reader with the passed size and content type.
+	Put(key string, content io.Reader, size int64, contentType string) error
+	// Get returns the content of the blob, which has to be closed by the caller.
+	Get(key string) (io.ReadCloser, error)
+	// Delete deletes the blob, deleting a missing blob is no error.
+	Delete(key string) error
+}
+
+// New returns the blob store configured by the passed configuration.
+func New(storeConfig config.BlobStoreConfig) (Store, error) {
+	switch storeConfig.Kind {
+	case config.BlobStoreKindLocal:
+		return NewLocal(storeConfig.Path)
+	case config.BlobStoreKindS3:
+		return NewS3(storeConfig.S3), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownStoreKind, storeConfig.Kind)
+}
+
+// validateKey rejects empty keys and keys which could escape the root of the store.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package blobstore
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local stores the blobs as files below a directory of the local file system.
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) (*Local, error) {
+	err := os.MkdirAll(root, 0o750)
+	if err != nil {
+		return nil, err
+	}
+	return &Local{
+		root: root,
+	}, nil
+}
+
+func (l *Local) Put(key string, content io.Reader, size int64, contentType string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return err
+	}
+
+	// the content is written to a temporary file first, so readers never see partial blobs
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+func (l *Local) Get(key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (l *Local) Delete(key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (l *Local) path(key string) (string, error) {
+	err := validateKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(l.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,53 @@
+package blobstore
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Local(t *testing.T) {
+	store, err := NewLocal(t.TempDir())
+	assert.NoError(t, err)
+
+	err = store.Put("transactions/1/receipt", strings.NewReader("receipt"), 7, "text/plain")
+	assert.NoError(t, err)
+
+	content, err := store.Get("transactions/1/receipt")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(content)
+	assert.NoError(t, err)
+	assert.NoError(t, content.Close())
+	assert.Equal(t, "receipt", string(data))
+
+	assert.NoError(t, store.Delete("transactions/1/receipt"))
+	assert.NoError(t, store.Delete("transactions/1/receipt"))
+	_, err = store.Get("transactions/1/receipt")
+	assert.ErrorIs(t, err, ErrBlobNotFound)
+}
+
+func Test_validateKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "transactions/1/receipt", wantErr: false},
+		{key: "", wantErr: true},
+		{key: "/etc/passwd", wantErr: true},
+		{key: "transactions/../../etc/passwd", wantErr: true},
+		{key: "transactions//receipt", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := validateKey(tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidKey)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package blobstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/config"
+)
+
+// S3 stores the blobs in a bucket of an S3-compatible object storage like MinIO. Buckets
+// are addressed path-style, requests are signed with AWS signature version 4.
+type S3 struct {
+	config config.S3BlobStoreConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewS3(config config.S3BlobStoreConfig) *S3 {
+	return &S3{
+		config: config,
+		client: &http.Client{Timeout: time.Minute},
+		now:    time.Now,
+	}
+}
+
+func (s *S3) Put(key string, content io.Reader, size int64, contentType string) error {
+	// the payload is signed, so it has to be read completely before sending it
+	data, err := io.ReadAll(io.LimitReader(content, size))
+	if err != nil {
+		return err
+	}
+
+	request, err := s.request(http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", contentType)
+	request.Header.Set("Content-Length", strconv.Itoa(len(data)))
+
+	response, err := s.do(request, data)
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func (s *S3) Get(key string) (io.ReadCloser, error) {
+	request, err := s.request(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.do(request, nil)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (s *S3) Delete(key string) error {
+	request, err := s.request(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := s.do(request, nil)
+	if err == ErrBlobNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return response.Body.Close()
+}
+
+func (s *S3) request(method, key string, data []byte) (*http.Request, error) {
+	err := validateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.config.Endpoint, "/"), s.config.Bucket, key)
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	return http.NewRequest(method, url, body)
+}
+
+// do signs and sends the request. Responses with an error status are closed and returned as error.
+func (s *S3) do(request *http.Request, data []byte) (*http.Response, error) {
+	payloadHash := sha256.Sum256(data)
+	sign(request, hex.EncodeToString(payloadHash[:]), s.config, s.now())
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, ErrBlobNotFound
+	}
+	if response.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		response.Body.Close()
+		return nil, fmt.Errorf("s3 %s %s: %s: %s", request.Method, request.URL.Path, response.Status, message)
+	}
+	return response, nil
+}
+
+// sign adds the AWS signature version 4 authorization header to the request. All headers
+// set on the request and the host are signed.
+func sign(request *http.Request, payloadHash string, config config.S3BlobStoreConfig, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host": request.URL.Host,
+	}
+	for name, values := range request.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, config.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+config.SecretAccessKey), date)
+	key = hmacSHA256(key, config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		config.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"net/netip"
 	"strings"
 
 	"github.com/knadh/koanf/providers/confmap"
@@ -12,7 +14,10 @@ import (
 type Config struct {
 	Port int `koanf:"port"`
 
-	DatabaseConfig DatabaseConfig `koanf:"database"`
+	DatabaseConfig   DatabaseConfig   `koanf:"database"`
+	BlobStoreConfig  BlobStoreConfig  `koanf:"blobstore"`
+	AttachmentConfig AttachmentConfig `koanf:"attachments"`
+	AuditConfig      AuditConfig      `koanf:"audit"`
 }
 
 type DatabaseConfig struct {
@@ -24,6 +29,42 @@ type DatabaseConfig struct {
 	SSLMode  string `koanf:"sslmode"`
 }
 
+// BlobStoreConfig configures where attachments are stored, either in a local
+// directory or in a bucket of an S3-compatible object storage.
+type BlobStoreConfig struct {
+	Kind string            `koanf:"kind"`
+	Path string            `koanf:"path"`
+	S3   S3BlobStoreConfig `koanf:"s3"`
+}
+
+type S3BlobStoreConfig struct {
+	Endpoint        string `koanf:"endpoint"`
+	Region          string `koanf:"region"`
+	Bucket          string `koanf:"bucket"`
+	AccessKeyID     string `koanf:"accesskeyid"`
+	SecretAccessKey string `koanf:"secretaccesskey"`
+}
+
+type AttachmentConfig struct {
+	// MaxSize is the maximum size of an attachment in bytes.
+	MaxSize int64 `koanf:"maxsize"`
+}
+
+// AuditConfig configures how the actors of recorded changes are identified. Users are only
+// named by the user header of trusted reverse proxies and API tokens only by configured tokens.
+type AuditConfig struct {
+	// TrustedProxies are the IP addresses or CIDR ranges of authenticating reverse proxies.
+	TrustedProxies []string `koanf:"trustedproxies"`
+	UserHeader     string   `koanf:"userheader"`
+	// APITokens maps the names of API tokens to the tokens passed as bearer tokens.
+	APITokens map[string]string `koanf:"apitokens"`
+}
+
+const (
+	BlobStoreKindLocal = "local"
+	BlobStoreKindS3    = "s3"
+)
+
 var (
 	k = koanf.New(".")
 )
@@ -31,7 +72,12 @@ var (
 func LoadConfig() (*Config, error) {
 	// load default values using the confmap provider
 	k.Load(confmap.Provider(map[string]any{
-		"port": 8080,
+		"port":                8080,
+		"blobstore.kind":      BlobStoreKindLocal,
+		"blobstore.path":      "data/attachments",
+		"blobstore.s3.region": "us-east-1",
+		"attachments.maxsize": 10 << 20,
+		"audit.userheader":    "X-Forwarded-User",
 	}, "."), nil)
 
 	// load configured values from environment variables
@@ -46,6 +92,9 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// lists set by environment variables are comma separated
+	config.AuditConfig.TrustedProxies = splitList(config.AuditConfig.TrustedProxies)
+
 	err = validate(&config)
 	if err != nil {
 		return nil, err
@@ -71,5 +120,69 @@ func validate(config *Config) error {
 		return errors.New("database name missing")
 	}
 
+	switch config.BlobStoreConfig.Kind {
+	case BlobStoreKindLocal:
+		if config.BlobStoreConfig.Path == "" {
+			return errors.New("blob store path missing")
+		}
+	case BlobStoreKindS3:
+		if config.BlobStoreConfig.S3.Endpoint == "" {
+			return errors.New("blob store s3 endpoint missing")
+		}
+		if config.BlobStoreConfig.S3.Bucket == "" {
+			return errors.New("blob store s3 bucket missing")
+		}
+		if config.BlobStoreConfig.S3.AccessKeyID == "" || config.BlobStoreConfig.S3.SecretAccessKey == "" {
+			return errors.New("blob store s3 credentials missing")
+		}
+	default:
+		return errors.New("unknown blob store kind")
+	}
+	if config.AttachmentConfig.MaxSize <= 0 {
+		return errors.New("attachment max size must be positive")
+	}
+
+	for _, proxy := range config.AuditConfig.TrustedProxies {
+		if _, err := ParseTrustedProxy(proxy); err != nil {
+			return fmt.Errorf("invalid trusted proxy %s", proxy)
+		}
+	}
+	if len(config.AuditConfig.TrustedProxies) > 0 && config.AuditConfig.UserHeader == "" {
+		return errors.New("audit user header missing")
+	}
+	for name, token := range config.AuditConfig.APITokens {
+		if len(token) < minAPITokenLength {
+			return fmt.Errorf("api token %s must have at least %d characters", name, minAPITokenLength)
+		}
+	}
+
 	return nil
 }
+
+func splitList(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// minAPITokenLength is the minimum length of API tokens, so they can't be guessed.
+const minAPITokenLength = 16
+
+// ParseTrustedProxy parses the IP address or CIDR range of a trusted proxy.
+func ParseTrustedProxy(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
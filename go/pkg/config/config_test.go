@@ -57,3 +57,36 @@ func Test_LoadConfig(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseTrustedProxy(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "should parse an address", value: "10.0.0.1", want: "10.0.0.1/32"},
+		{name: "should parse a range", value: "10.1.2.3/8", want: "10.0.0.0/8"},
+		{name: "should reject a host name", value: "proxy", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTrustedProxy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTrustedProxy() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && got.String() != tt.want {
+				t.Errorf("ParseTrustedProxy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_splitList(t *testing.T) {
+	got := splitList([]string{"10.0.0.1, ::1", "", "192.168.0.0/16"})
+	want := []string{"10.0.0.1", "::1", "192.168.0.0/16"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitList() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,2 @@
+ALTER TABLE public.categories
+  ADD COLUMN transfer BOOLEAN NOT NULL DEFAULT false;
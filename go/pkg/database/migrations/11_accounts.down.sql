@@ -0,0 +1,5 @@
+ALTER TABLE public.transactions
+  DROP COLUMN account_id;
+
+DROP TABLE public.account_valuations;
+DROP TABLE public.accounts;
@@ -0,0 +1,27 @@
+CREATE TABLE public.accounts (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL,
+  kind TEXT NOT NULL,
+  iban TEXT UNIQUE,
+  opening_balance FLOAT,
+  opening_date DATE
+);
+
+CREATE TABLE public.account_valuations (
+  id SERIAL PRIMARY KEY,
+  account_id INTEGER NOT NULL,
+  date DATE NOT NULL,
+  value FLOAT NOT NULL,
+  UNIQUE (account_id, date)
+);
+
+-- all transactions imported so far belong to the default account
+INSERT INTO public.accounts (name, kind) VALUES ('Default', 'bank');
+
+ALTER TABLE public.transactions
+  ADD COLUMN account_id INTEGER;
+UPDATE public.transactions
+  SET account_id = (SELECT MIN(id) FROM public.accounts);
+ALTER TABLE public.transactions
+  ALTER COLUMN account_id SET NOT NULL;
+CREATE INDEX ON public.transactions(account_id, booking_date);
@@ -0,0 +1,9 @@
+CREATE TABLE public.account_reconciliations (
+  id SERIAL PRIMARY KEY,
+  account_id INTEGER NOT NULL,
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL,
+  balance FLOAT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX ON public.account_reconciliations(account_id);
@@ -0,0 +1 @@
+DROP TABLE public.period_locks;
@@ -0,0 +1,8 @@
+CREATE TABLE public.period_locks (
+  id SERIAL PRIMARY KEY,
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX ON public.period_locks(start_date, end_date);
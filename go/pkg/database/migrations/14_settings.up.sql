@@ -0,0 +1,4 @@
+CREATE TABLE public.settings (
+  key TEXT PRIMARY KEY,
+  value JSONB NOT NULL
+);
@@ -0,0 +1 @@
+DROP TABLE public.fiscal_months;
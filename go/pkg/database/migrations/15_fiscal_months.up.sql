@@ -0,0 +1,7 @@
+CREATE TABLE public.fiscal_months (
+  year INTEGER NOT NULL,
+  month INTEGER NOT NULL,
+  start_date DATE NOT NULL,
+  overridden BOOLEAN NOT NULL DEFAULT false,
+  PRIMARY KEY (year, month)
+);
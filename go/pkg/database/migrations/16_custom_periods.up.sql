@@ -0,0 +1,6 @@
+CREATE TABLE public.custom_periods (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE,
+  start_date DATE NOT NULL,
+  end_date DATE NOT NULL
+);
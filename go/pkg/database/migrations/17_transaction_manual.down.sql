@@ -0,0 +1,2 @@
+ALTER TABLE public.transactions
+  DROP COLUMN manual;
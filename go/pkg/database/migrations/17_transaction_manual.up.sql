@@ -0,0 +1,2 @@
+ALTER TABLE public.transactions
+  ADD COLUMN manual BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP TABLE public.transaction_attachments;
+
+ALTER TABLE public.transactions
+  DROP COLUMN note;
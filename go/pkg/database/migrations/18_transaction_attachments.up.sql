@@ -0,0 +1,13 @@
+ALTER TABLE public.transactions
+  ADD COLUMN note TEXT;
+
+CREATE TABLE public.transaction_attachments (
+  id SERIAL PRIMARY KEY,
+  transaction_id INTEGER NOT NULL,
+  file_name TEXT NOT NULL,
+  content_type TEXT NOT NULL,
+  size BIGINT NOT NULL,
+  blob_key TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX ON public.transaction_attachments(transaction_id);
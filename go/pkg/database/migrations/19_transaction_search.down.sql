@@ -0,0 +1,6 @@
+DROP INDEX public.transactions_search_trgm_idx;
+DROP INDEX public.transactions_search_idx;
+DROP FUNCTION public.transaction_search_text(TEXT, TEXT, TEXT, TEXT, TEXT[]);
+
+ALTER TABLE public.transactions
+  DROP COLUMN tags;
@@ -0,0 +1,19 @@
+ALTER TABLE public.transactions
+  ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- the searchable text of a transaction, declared immutable so it can be indexed
+CREATE FUNCTION public.transaction_search_text(recipient TEXT, booking_text TEXT, purpose TEXT, note TEXT, tags TEXT[])
+RETURNS TEXT
+LANGUAGE SQL
+IMMUTABLE
+AS $$
+  SELECT concat_ws(' ', recipient, booking_text, purpose, note, array_to_string(tags, ' '))
+$$;
+
+CREATE INDEX transactions_search_idx ON public.transactions
+  USING GIN (to_tsvector('german', public.transaction_search_text(recipient, booking_text, purpose, note, tags)));
+CREATE INDEX transactions_search_trgm_idx ON public.transactions
+  USING GIN (public.transaction_search_text(recipient, booking_text, purpose, note, tags) gin_trgm_ops);
+CREATE INDEX ON public.transactions USING GIN (tags);
@@ -0,0 +1,7 @@
+DROP TRIGGER audit_category_rules ON public.category_rules;
+DROP TRIGGER audit_categories ON public.categories;
+DROP TRIGGER audit_transactions ON public.transactions;
+DROP FUNCTION public.audit_record();
+
+DROP TABLE public.audit_log;
+DROP FUNCTION public.audit_log_append_only();
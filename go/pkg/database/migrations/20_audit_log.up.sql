@@ -0,0 +1,84 @@
+CREATE TABLE public.audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  entity_type TEXT NOT NULL,
+  entity_id INTEGER NOT NULL,
+  action TEXT NOT NULL,
+  actor_type TEXT NOT NULL,
+  actor_name TEXT,
+  old_values JSONB,
+  new_values JSONB,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX ON public.audit_log(entity_type, entity_id);
+
+-- entries of the audit log can't be changed or removed
+CREATE FUNCTION public.audit_log_append_only()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+BEGIN
+  RAISE EXCEPTION 'audit log is append-only';
+END;
+$$;
+
+CREATE TRIGGER audit_log_append_only
+  BEFORE UPDATE OR DELETE OR TRUNCATE ON public.audit_log
+  FOR EACH STATEMENT EXECUTE FUNCTION public.audit_log_append_only();
+
+-- records the changed columns of a row. The first argument is the entity type, the following
+-- arguments are columns, which aren't recorded. The actor is read from the settings
+-- bookkeeper.actor_type and bookkeeper.actor_name of the database transaction.
+CREATE FUNCTION public.audit_record()
+RETURNS TRIGGER
+LANGUAGE plpgsql
+AS $$
+DECLARE
+  ignored TEXT[];
+  row_id INTEGER;
+  old_row JSONB;
+  new_row JSONB;
+BEGIN
+  ignored := TG_ARGV[1:TG_NARGS - 1];
+
+  IF TG_OP = 'INSERT' THEN
+    row_id := NEW.id;
+    new_row := to_jsonb(NEW) - ignored;
+  ELSIF TG_OP = 'DELETE' THEN
+    row_id := OLD.id;
+    old_row := to_jsonb(OLD) - ignored;
+  ELSE
+    row_id := NEW.id;
+    SELECT jsonb_object_agg(o.key, o.value), jsonb_object_agg(o.key, n.value)
+    INTO old_row, new_row
+    FROM jsonb_each(to_jsonb(OLD) - ignored) AS o
+    JOIN jsonb_each(to_jsonb(NEW) - ignored) AS n ON n.key = o.key
+    WHERE o.value IS DISTINCT FROM n.value;
+
+    IF old_row IS NULL THEN
+      RETURN NULL;
+    END IF;
+  END IF;
+
+  INSERT INTO public.audit_log (entity_type, entity_id, action, actor_type, actor_name, old_values, new_values)
+  VALUES (
+    TG_ARGV[0],
+    row_id,
+    lower(TG_OP),
+    COALESCE(NULLIF(current_setting('bookkeeper.actor_type', true), ''), 'system'),
+    NULLIF(current_setting('bookkeeper.actor_name', true), ''),
+    old_row,
+    new_row
+  );
+  RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER audit_transactions
+  AFTER INSERT OR UPDATE OR DELETE ON public.transactions
+  FOR EACH ROW EXECUTE FUNCTION public.audit_record('transaction', 'hash');
+CREATE TRIGGER audit_categories
+  AFTER INSERT OR UPDATE OR DELETE ON public.categories
+  FOR EACH ROW EXECUTE FUNCTION public.audit_record('category');
+CREATE TRIGGER audit_category_rules
+  AFTER INSERT OR UPDATE OR DELETE ON public.category_rules
+  FOR EACH ROW EXECUTE FUNCTION public.audit_record('rule', 'hit_count', 'shadowed_count', 'last_hit_date');
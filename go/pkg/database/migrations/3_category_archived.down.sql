@@ -0,0 +1,2 @@
+ALTER TABLE public.categories
+  DROP COLUMN archived;
@@ -0,0 +1,2 @@
+ALTER TABLE public.categories
+  ADD COLUMN archived BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+ALTER TABLE public.category_rules
+  DROP COLUMN hit_count,
+  DROP COLUMN shadowed_count,
+  DROP COLUMN last_hit_date;
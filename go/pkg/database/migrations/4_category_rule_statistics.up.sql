@@ -0,0 +1,4 @@
+ALTER TABLE public.category_rules
+  ADD COLUMN hit_count BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN shadowed_count BIGINT NOT NULL DEFAULT 0,
+  ADD COLUMN last_hit_date DATE;
@@ -0,0 +1,5 @@
+ALTER TABLE public.transactions
+  DROP COLUMN payee_id;
+
+DROP TABLE public.payee_aliases;
+DROP TABLE public.payees;
@@ -0,0 +1,15 @@
+CREATE TABLE public.payees (
+  id SERIAL PRIMARY KEY,
+  name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE public.payee_aliases (
+  id SERIAL PRIMARY KEY,
+  payee_id INTEGER NOT NULL,
+  regex TEXT NOT NULL
+);
+CREATE INDEX ON public.payee_aliases(payee_id);
+
+ALTER TABLE public.transactions
+  ADD COLUMN payee_id INTEGER;
+CREATE INDEX ON public.transactions(payee_id);
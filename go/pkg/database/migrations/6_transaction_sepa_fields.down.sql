@@ -0,0 +1,8 @@
+ALTER TABLE public.transactions
+  DROP COLUMN iban,
+  DROP COLUMN bic,
+  DROP COLUMN creditor_id,
+  DROP COLUMN mandate_reference,
+  DROP COLUMN end_to_end_reference,
+  DROP COLUMN card_number,
+  DROP COLUMN card_terminal;
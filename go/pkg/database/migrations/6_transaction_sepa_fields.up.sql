@@ -0,0 +1,10 @@
+ALTER TABLE public.transactions
+  ADD COLUMN iban TEXT,
+  ADD COLUMN bic TEXT,
+  ADD COLUMN creditor_id TEXT,
+  ADD COLUMN mandate_reference TEXT,
+  ADD COLUMN end_to_end_reference TEXT,
+  ADD COLUMN card_number TEXT,
+  ADD COLUMN card_terminal TEXT;
+CREATE INDEX ON public.transactions(iban);
+CREATE INDEX ON public.transactions(creditor_id);
@@ -0,0 +1,2 @@
+DROP TABLE public.budgets;
+DROP TABLE public.budget_templates;
@@ -0,0 +1,14 @@
+CREATE TABLE public.budget_templates (
+  category_id INTEGER PRIMARY KEY,
+  amount FLOAT NOT NULL
+);
+
+CREATE TABLE public.budgets (
+  id SERIAL PRIMARY KEY,
+  category_id INTEGER NOT NULL,
+  year INTEGER NOT NULL,
+  month INTEGER NOT NULL,
+  amount FLOAT NOT NULL,
+  UNIQUE (category_id, year, month)
+);
+CREATE INDEX ON public.budgets(year, month);
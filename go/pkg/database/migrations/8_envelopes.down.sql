@@ -0,0 +1,2 @@
+DROP TABLE public.envelope_movements;
+DROP TABLE public.envelopes;
@@ -0,0 +1,19 @@
+CREATE TABLE public.envelopes (
+  category_id INTEGER PRIMARY KEY,
+  start_year INTEGER NOT NULL,
+  start_month INTEGER NOT NULL
+);
+
+CREATE TABLE public.envelope_movements (
+  id SERIAL PRIMARY KEY,
+  year INTEGER NOT NULL,
+  month INTEGER NOT NULL,
+  from_category_id INTEGER,
+  to_category_id INTEGER NOT NULL,
+  transaction_id INTEGER,
+  amount FLOAT NOT NULL,
+  description TEXT,
+  created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX ON public.envelope_movements(year, month);
+CREATE INDEX ON public.envelope_movements(transaction_id);
@@ -0,0 +1,13 @@
+CREATE TABLE public.subscriptions (
+  id SERIAL PRIMARY KEY,
+  key TEXT NOT NULL,
+  recipient TEXT NOT NULL,
+  payee_id INTEGER,
+  interval TEXT NOT NULL,
+  amount FLOAT NOT NULL,
+  previous_amount FLOAT NOT NULL,
+  occurrences INTEGER NOT NULL,
+  last_date DATE NOT NULL,
+  next_date DATE NOT NULL
+);
+CREATE INDEX ON public.subscriptions(next_date);
@@ -0,0 +1,158 @@
+package account
+
+import (
+	"fmt"
+	"time"
+)
+
+type Kind string
+
+const (
+	// KindBank accounts are imported from bank statements, their balances are
+	// reconstructed from the running balance of the transactions.
+	KindBank Kind = "bank"
+	// KindAsset accounts are valued manually, e.g. a car or a depot.
+	KindAsset Kind = "asset"
+	// KindLiability accounts are valued manually with the positive amount owed, e.g. a loan.
+	KindLiability Kind = "liability"
+)
+
+func ParseKind(value string) (Kind, error) {
+	switch kind := Kind(value); kind {
+	case KindBank, KindAsset, KindLiability:
+		return kind, nil
+	}
+	return "", fmt.Errorf("%w: unknown kind %s", ErrInvalidAccount, value)
+}
+
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityMonth Granularity = "month"
+)
+
+func ParseGranularity(value string) (Granularity, error) {
+	switch granularity := Granularity(value); granularity {
+	case GranularityDay, GranularityMonth:
+		return granularity, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownGranularity, value)
+}
+
+type Account struct {
+	ID   int64   `json:"id"`
+	Name string  `json:"name"`
+	Kind Kind    `json:"kind"`
+	IBAN *string `json:"iban"`
+	// OpeningBalance is the balance before the first transaction or valuation.
+	OpeningBalance *float64 `json:"openingBalance"`
+	// OpeningDate is the first day the account is part of the net worth.
+	OpeningDate *time.Time `json:"openingDate"`
+}
+
+type AccountCreateRequest struct {
+	Name           string   `json:"name"`
+	Kind           string   `json:"kind"`
+	IBAN           *string  `json:"iban"`
+	OpeningBalance *float64 `json:"openingBalance"`
+	OpeningDate    *string  `json:"openingDate"`
+}
+
+// Valuation is the manually entered value of an asset or liability account at a date.
+type Valuation struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"accountID"`
+	Date      time.Time `json:"date"`
+	Value     float64   `json:"value"`
+}
+
+type ValuationCreateRequest struct {
+	Date  string   `json:"date"`
+	Value *float64 `json:"value"`
+}
+
+// Point is the balance or value at the end of a day.
+type Point struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+type BalanceHistory struct {
+	Account     Account     `json:"account"`
+	Granularity Granularity `json:"granularity"`
+	Points      []Point     `json:"points"`
+}
+
+type NetWorthPoint struct {
+	Date        time.Time `json:"date"`
+	Assets      float64   `json:"assets"`
+	Liabilities float64   `json:"liabilities"`
+	NetWorth    float64   `json:"netWorth"`
+}
+
+type NetWorth struct {
+	Granularity Granularity      `json:"granularity"`
+	Points      []NetWorthPoint  `json:"points"`
+	Accounts    []BalanceHistory `json:"accounts"`
+}
+
+// History returns the balance at the end of every day or month from from to to. The known
+// balances have to be ordered ascending and contain at most one balance per day. Days without
+// a known balance keep the previous balance, days before the first one use the initial balance.
+// Days before the opening date have no balance, the last point of a month may be the to date.
+func History(account Account, initial float64, known []Point, from, to time.Time, granularity Granularity) []Point {
+	points := make([]Point, 0)
+
+	value := initial
+	i := 0
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for i < len(known) && !known[i].Date.After(day) {
+			value = known[i].Value
+			i++
+		}
+
+		if granularity == GranularityMonth && day.AddDate(0, 0, 1).Month() == day.Month() && !day.Equal(to) {
+			continue
+		}
+
+		point := Point{Date: day, Value: value}
+		if account.OpeningDate != nil && day.Before(*account.OpeningDate) {
+			point.Value = 0
+		}
+		points = append(points, point)
+	}
+
+	return points
+}
+
+// CalculateNetWorth sums the histories of all accounts, which have to share the same dates.
+// Bank and asset accounts are assets, the values of liability accounts are liabilities.
+func CalculateNetWorth(histories []BalanceHistory) []NetWorthPoint {
+	if len(histories) == 0 {
+		return []NetWorthPoint{}
+	}
+
+	points := make([]NetWorthPoint, len(histories[0].Points))
+	for i := range points {
+		points[i].Date = histories[0].Points[i].Date
+	}
+
+	for _, history := range histories {
+		for i, point := range history.Points {
+			if i >= len(points) {
+				break
+			}
+			if history.Account.Kind == KindLiability {
+				points[i].Liabilities += point.Value
+			} else {
+				points[i].Assets += point.Value
+			}
+		}
+	}
+
+	for i := range points {
+		points[i].NetWorth = points[i].Assets - points[i].Liabilities
+	}
+	return points
+}
@@ -0,0 +1,96 @@
+package account
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_History(t *testing.T) {
+	known := []Point{
+		{Date: date(2024, 1, 2), Value: 900},
+		{Date: date(2024, 1, 5), Value: 1200},
+		{Date: date(2024, 2, 3), Value: 700},
+	}
+
+	tests := []struct {
+		name        string
+		account     Account
+		from        time.Time
+		to          time.Time
+		granularity Granularity
+		want        []Point
+	}{
+		{
+			name:        "should fill gaps with the previous balance",
+			account:     Account{Kind: KindBank},
+			from:        date(2024, 1, 1),
+			to:          date(2024, 1, 6),
+			granularity: GranularityDay,
+			want: []Point{
+				{Date: date(2024, 1, 1), Value: 1000},
+				{Date: date(2024, 1, 2), Value: 900},
+				{Date: date(2024, 1, 3), Value: 900},
+				{Date: date(2024, 1, 4), Value: 900},
+				{Date: date(2024, 1, 5), Value: 1200},
+				{Date: date(2024, 1, 6), Value: 1200},
+			},
+		},
+		{
+			name:        "should return the balance at the end of every month",
+			account:     Account{Kind: KindBank},
+			from:        date(2024, 1, 15),
+			to:          date(2024, 3, 10),
+			granularity: GranularityMonth,
+			want: []Point{
+				{Date: date(2024, 1, 31), Value: 1200},
+				{Date: date(2024, 2, 29), Value: 700},
+				{Date: date(2024, 3, 10), Value: 700},
+			},
+		},
+		{
+			name:        "should have no balance before the opening date",
+			account:     Account{Kind: KindAsset, OpeningDate: func() *time.Time { d := date(2024, 1, 2); return &d }()},
+			from:        date(2024, 1, 1),
+			to:          date(2024, 1, 2),
+			granularity: GranularityDay,
+			want: []Point{
+				{Date: date(2024, 1, 1), Value: 0},
+				{Date: date(2024, 1, 2), Value: 900},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, History(tt.account, 1000, known, tt.from, tt.to, tt.granularity))
+		})
+	}
+}
+
+func Test_CalculateNetWorth(t *testing.T) {
+	histories := []BalanceHistory{
+		{
+			Account: Account{Kind: KindBank},
+			Points:  []Point{{Date: date(2024, 1, 31), Value: 2000}, {Date: date(2024, 2, 29), Value: -100}},
+		},
+		{
+			Account: Account{Kind: KindAsset},
+			Points:  []Point{{Date: date(2024, 1, 31), Value: 15000}, {Date: date(2024, 2, 29), Value: 14500}},
+		},
+		{
+			Account: Account{Kind: KindLiability},
+			Points:  []Point{{Date: date(2024, 1, 31), Value: 8000}, {Date: date(2024, 2, 29), Value: 7800}},
+		},
+	}
+
+	assert.Equal(t, []NetWorthPoint{
+		{Date: date(2024, 1, 31), Assets: 17000, Liabilities: 8000, NetWorth: 9000},
+		{Date: date(2024, 2, 29), Assets: 14400, Liabilities: 7800, NetWorth: 6600},
+	}, CalculateNetWorth(histories))
+}
@@ -0,0 +1,329 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/account"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service         *account.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         account.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	accountsAPI := router.Group("/accounts")
+	accountsAPI.GET("", handler.List)
+	accountsAPI.POST("", handler.Create)
+	accountsAPI.GET("/net-worth", handler.NetWorth)
+
+	accountAPI := router.Group("/account")
+	accountAPI.GET("/:id", handler.Get)
+	accountAPI.DELETE("/:id", handler.Delete)
+	accountAPI.GET("/:id/balances", handler.BalanceHistory)
+	accountAPI.GET("/:id/valuations", handler.ListValuations)
+	accountAPI.POST("/:id/valuations", handler.CreateValuation)
+	accountAPI.DELETE("/:id/valuations/:valuationID", handler.DeleteValuation)
+	accountAPI.GET("/:id/reconciliation", handler.Reconcile)
+	accountAPI.GET("/:id/reconciliations", handler.ListReconciliations)
+	accountAPI.POST("/:id/reconciliations", handler.CreateReconciliation)
+	accountAPI.DELETE("/:id/reconciliations/:reconciliationID", handler.DeleteReconciliation)
+
+	return handler
+}
+
+func (h *Handler) List(c *gin.Context) {
+	accounts, err := h.Service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var createRequest account.AccountCreateRequest
+	err := c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.Service.Create(createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, account)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	account, err := h.Service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.Delete(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) BalanceHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, granularity, err := h.parseRange(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	history, err := h.Service.BalanceHistory(id, from, to, granularity)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *Handler) NetWorth(c *gin.Context) {
+	from, to, granularity, err := h.parseRange(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	netWorth, err := h.Service.NetWorth(from, to, granularity)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, netWorth)
+}
+
+func (h *Handler) ListValuations(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	valuations, err := h.Service.ListValuations(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, valuations)
+}
+
+func (h *Handler) CreateValuation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createRequest account.ValuationCreateRequest
+	err = c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	valuation, err := h.Service.CreateValuation(id, createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, valuation)
+}
+
+func (h *Handler) DeleteValuation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	valuationID, err := strconv.ParseInt(c.Param("valuationID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteValuation(id, valuationID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Reconcile(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, _, err := h.parseRange(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	report, err := h.Service.Reconcile(id, from, to)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) ListReconciliations(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, _, err := h.parseRange(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	reconciliations, err := h.Service.ListReconciliations(id, from, to)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reconciliations)
+}
+
+func (h *Handler) CreateReconciliation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createRequest account.ReconciliationRequest
+	err = c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reconciliation, err := h.Service.CreateReconciliation(id, createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reconciliation)
+}
+
+func (h *Handler) DeleteReconciliation(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reconciliationID, err := strconv.ParseInt(c.Param("reconciliationID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteReconciliation(id, reconciliationID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseRange parses the period selector or the from and to parameters and the granularity.
+func (h *Handler) parseRange(c *gin.Context) (time.Time, time.Time, account.Granularity, error) {
+	from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, "", err
+	}
+
+	granularity := account.GranularityDay
+	if rawGranularity := c.Query("granularity"); rawGranularity != "" {
+		granularity, err = account.ParseGranularity(rawGranularity)
+		if err != nil {
+			return time.Time{}, time.Time{}, "", err
+		}
+	}
+
+	return from, to, granularity, nil
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, account.ErrAccountNotFound),
+		errors.Is(err, account.ErrValuationNotFound),
+		errors.Is(err, account.ErrReconciliationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, account.ErrInvalidAccount),
+		errors.Is(err, account.ErrInvalidValuation),
+		errors.Is(err, account.ErrInvalidReconciliation),
+		errors.Is(err, account.ErrUnknownGranularity),
+		errors.Is(err, account.ErrInvalidRange),
+		errors.Is(err, interval.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, account.ErrAccountInUse), errors.Is(err, account.ErrInconsistentBalances):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
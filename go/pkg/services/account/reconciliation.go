@@ -0,0 +1,97 @@
+package account
+
+import (
+	"math"
+	"time"
+)
+
+// balanceTolerance is the difference allowed between two balances due to floating point errors.
+const balanceTolerance = 0.005
+
+type IssueKind string
+
+const (
+	// IssueKindGap means the balance changed more than the amount of the transaction,
+	// so transactions between the two transactions are missing.
+	IssueKindGap IssueKind = "gap"
+	// IssueKindDuplicate means the transaction repeats the previous one including
+	// its balance, so it has most likely been imported twice.
+	IssueKindDuplicate IssueKind = "duplicate"
+)
+
+// Entry is a transaction relevant to check the continuity of the balances.
+type Entry struct {
+	ID      int64     `json:"id"`
+	Date    time.Time `json:"date"`
+	Amount  float64   `json:"amount"`
+	Balance float64   `json:"balance"`
+}
+
+// Issue is an inconsistency between a transaction and the previous one. For gaps the
+// difference is the sum of the missing transactions booked between from and to.
+type Issue struct {
+	Kind                  IssueKind `json:"kind"`
+	TransactionID         int64     `json:"transactionID"`
+	PreviousTransactionID int64     `json:"previousTransactionID"`
+	From                  time.Time `json:"from"`
+	To                    time.Time `json:"to"`
+	ExpectedBalance       float64   `json:"expectedBalance"`
+	Balance               float64   `json:"balance"`
+	Difference            float64   `json:"difference"`
+}
+
+type Reconciliation struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"accountID"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ReconciliationRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ReconciliationReport struct {
+	Account Account   `json:"account"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Checked int       `json:"checked"`
+	Issues  []Issue   `json:"issues"`
+	// Reconciled are the periods overlapping the checked range marked as reconciled.
+	Reconciled []Reconciliation `json:"reconciled"`
+}
+
+// CheckContinuity checks that the balance of every entry equals the balance of the previous
+// entry plus its amount. The entries have to be ordered from the oldest to the newest.
+func CheckContinuity(entries []Entry) []Issue {
+	issues := make([]Issue, 0)
+	for i := 1; i < len(entries); i++ {
+		previous, current := entries[i-1], entries[i]
+
+		expected := previous.Balance + current.Amount
+		if math.Abs(expected-current.Balance) < balanceTolerance {
+			continue
+		}
+
+		issue := Issue{
+			Kind:                  IssueKindGap,
+			TransactionID:         current.ID,
+			PreviousTransactionID: previous.ID,
+			From:                  previous.Date,
+			To:                    current.Date,
+			ExpectedBalance:       expected,
+			Balance:               current.Balance,
+			Difference:            current.Balance - expected,
+		}
+		if current.Date.Equal(previous.Date) &&
+			math.Abs(current.Amount-previous.Amount) < balanceTolerance &&
+			math.Abs(current.Balance-previous.Balance) < balanceTolerance {
+			issue.Kind = IssueKindDuplicate
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,86 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CheckContinuity(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    []Issue
+	}{
+		{
+			name:    "should accept no entries",
+			entries: []Entry{},
+			want:    []Issue{},
+		},
+		{
+			name: "should accept continuous balances",
+			entries: []Entry{
+				{ID: 3, Date: date(2024, 1, 1), Amount: -10, Balance: 990},
+				{ID: 2, Date: date(2024, 1, 2), Amount: 100.1, Balance: 1090.1},
+				{ID: 1, Date: date(2024, 1, 2), Amount: -0.2, Balance: 1089.9},
+			},
+			want: []Issue{},
+		},
+		{
+			name: "should report missing transactions",
+			entries: []Entry{
+				{ID: 3, Date: date(2024, 1, 1), Amount: -10, Balance: 990},
+				{ID: 2, Date: date(2024, 3, 1), Amount: -20, Balance: 900},
+				{ID: 1, Date: date(2024, 3, 2), Amount: -50, Balance: 850},
+			},
+			want: []Issue{
+				{
+					Kind:                  IssueKindGap,
+					TransactionID:         2,
+					PreviousTransactionID: 3,
+					From:                  date(2024, 1, 1),
+					To:                    date(2024, 3, 1),
+					ExpectedBalance:       970,
+					Balance:               900,
+					Difference:            -70,
+				},
+			},
+		},
+		{
+			name: "should report double imported transactions",
+			entries: []Entry{
+				{ID: 3, Date: date(2024, 1, 1), Amount: -10, Balance: 990},
+				{ID: 2, Date: date(2024, 1, 1), Amount: -10, Balance: 990},
+			},
+			want: []Issue{
+				{
+					Kind:                  IssueKindDuplicate,
+					TransactionID:         2,
+					PreviousTransactionID: 3,
+					From:                  date(2024, 1, 1),
+					To:                    date(2024, 1, 1),
+					ExpectedBalance:       980,
+					Balance:               990,
+					Difference:            10,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckContinuity(tt.entries)
+			assert.Equal(t, len(tt.want), len(got))
+			for i := range tt.want {
+				assert.Equal(t, tt.want[i].Kind, got[i].Kind)
+				assert.Equal(t, tt.want[i].TransactionID, got[i].TransactionID)
+				assert.Equal(t, tt.want[i].PreviousTransactionID, got[i].PreviousTransactionID)
+				assert.Equal(t, tt.want[i].From, got[i].From)
+				assert.Equal(t, tt.want[i].To, got[i].To)
+				assert.InDelta(t, tt.want[i].ExpectedBalance, got[i].ExpectedBalance, 0.001)
+				assert.InDelta(t, tt.want[i].Balance, got[i].Balance, 0.001)
+				assert.InDelta(t, tt.want[i].Difference, got[i].Difference, 0.001)
+			}
+		})
+	}
+}
@@ -0,0 +1,623 @@
+package account
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/database"
+)
+
+var (
+	ErrAccountNotFound    = errors.New("account not found")
+	ErrValuationNotFound  = errors.New("valuation not found")
+	ErrInvalidAccount     = errors.New("invalid account")
+	ErrInvalidValuation   = errors.New("invalid valuation")
+	ErrAccountInUse       = errors.New("account still has transactions")
+	ErrUnknownGranularity = errors.New("unknown granularity")
+	ErrInvalidRange       = errors.New("from must not be after to")
+
+	ErrReconciliationNotFound = errors.New("reconciliation not found")
+	ErrInvalidReconciliation  = errors.New("invalid reconciliation")
+	ErrInconsistentBalances   = errors.New("balances of the period are inconsistent")
+)
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+const accountColumns = `id, name, kind, iban, opening_balance, opening_date`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAccount(row scanner) (*Account, error) {
+	var (
+		account        Account
+		iban           sql.NullString
+		openingBalance sql.NullFloat64
+		openingDate    sql.NullTime
+	)
+	err := row.Scan(&account.ID, &account.Name, &account.Kind, &iban, &openingBalance, &openingDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if iban.Valid {
+		account.IBAN = &iban.String
+	}
+	if openingBalance.Valid {
+		account.OpeningBalance = &openingBalance.Float64
+	}
+	if openingDate.Valid {
+		account.OpeningDate = &openingDate.Time
+	}
+	return &account, nil
+}
+
+func (s *Service) List() ([]Account, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM accounts
+		ORDER BY id;
+	`, accountColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := make([]Account, 0)
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (s *Service) Get(id int64) (*Account, error) {
+	account, err := scanAccount(s.db.QueryRow(fmt.Sprintf(`
+		SELECT %s
+		FROM accounts
+		WHERE id = $1;
+	`, accountColumns), id))
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+	return account, err
+}
+
+// Default returns the bank account statements are imported into, if no account is passed.
+func (s *Service) Default() (*Account, error) {
+	account, err := scanAccount(s.db.QueryRow(fmt.Sprintf(`
+		SELECT %s
+		FROM accounts
+		WHERE kind = $1
+		ORDER BY id
+		LIMIT 1;
+	`, accountColumns), KindBank))
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	}
+	return account, err
+}
+
+func (s *Service) Create(request AccountCreateRequest) (*Account, error) {
+	if request.Name == "" {
+		return nil, fmt.Errorf("%w: name missing", ErrInvalidAccount)
+	}
+	kind, err := ParseKind(request.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var openingDate *time.Time
+	if request.OpeningDate != nil {
+		date, err := time.Parse(time.DateOnly, *request.OpeningDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAccount, err)
+		}
+		openingDate = &date
+	}
+
+	var id int64
+	err = s.db.QueryRow(`
+		INSERT INTO accounts (name, kind, iban, opening_balance, opening_date)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;
+	`, request.Name, kind, request.IBAN, request.OpeningBalance, openingDate).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(id)
+}
+
+// Delete deletes the account with all of its valuations and reconciliations. Accounts
+// with transactions can't be deleted.
+func (s *Service) Delete(id int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var inUse bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM transactions
+			WHERE account_id = $1
+		);
+	`, id).Scan(&inUse)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return ErrAccountInUse
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM account_valuations
+		WHERE account_id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM account_reconciliations
+		WHERE account_id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM accounts
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrAccountNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (s *Service) ListValuations(accountID int64) ([]Valuation, error) {
+	rows, err := s.db.Query(`
+		SELECT id, account_id, date, value
+		FROM account_valuations
+		WHERE account_id = $1
+		ORDER BY date;
+	`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	valuations := make([]Valuation, 0)
+	for rows.Next() {
+		var valuation Valuation
+		err = rows.Scan(&valuation.ID, &valuation.AccountID, &valuation.Date, &valuation.Value)
+		if err != nil {
+			return nil, err
+		}
+		valuations = append(valuations, valuation)
+	}
+
+	return valuations, rows.Err()
+}
+
+// CreateValuation sets the value of an asset or liability account at the passed
+// date. An existing valuation at the same date is replaced.
+func (s *Service) CreateValuation(accountID int64, request ValuationCreateRequest) (*Valuation, error) {
+	account, err := s.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Kind == KindBank {
+		return nil, fmt.Errorf("%w: bank accounts are valued by their transactions", ErrInvalidValuation)
+	}
+	if request.Value == nil {
+		return nil, fmt.Errorf("%w: value missing", ErrInvalidValuation)
+	}
+	date, err := time.Parse(time.DateOnly, request.Date)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidValuation, err)
+	}
+
+	valuation := Valuation{
+		AccountID: accountID,
+		Date:      date,
+		Value:     *request.Value,
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO account_valuations (account_id, date, value)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (account_id, date) DO UPDATE
+		SET value = EXCLUDED.value
+		RETURNING id;
+	`, accountID, date, valuation.Value).Scan(&valuation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &valuation, nil
+}
+
+func (s *Service) DeleteValuation(accountID, valuationID int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM account_valuations
+		WHERE id = $1 AND account_id = $2;
+	`, valuationID, accountID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrValuationNotFound
+	}
+	return nil
+}
+
+// BalanceHistory returns the balance of the account at the end of every day or month between from and to.
+func (s *Service) BalanceHistory(accountID int64, from, to time.Time, granularity Granularity) (*BalanceHistory, error) {
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+
+	account, err := s.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.balanceHistory(*account, from, to, granularity)
+}
+
+// NetWorth returns the sum of the balances of all accounts at the end of every day or month between from and to.
+func (s *Service) NetWorth(from, to time.Time, granularity Granularity) (*NetWorth, error) {
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+
+	accounts, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]BalanceHistory, 0, len(accounts))
+	for _, account := range accounts {
+		history, err := s.balanceHistory(account, from, to, granularity)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, *history)
+	}
+
+	return &NetWorth{
+		Granularity: granularity,
+		Points:      CalculateNetWorth(histories),
+		Accounts:    histories,
+	}, nil
+}
+
+func (s *Service) balanceHistory(account Account, from, to time.Time, granularity Granularity) (*BalanceHistory, error) {
+	var (
+		initial float64
+		known   []Point
+		err     error
+	)
+	if account.OpeningBalance != nil {
+		initial = *account.OpeningBalance
+	}
+
+	if account.Kind == KindBank {
+		known, err = s.endOfDayBalances(account.ID, to)
+		if err != nil {
+			return nil, err
+		}
+		if account.OpeningBalance == nil {
+			initial, err = s.balanceBeforeFirstTransaction(account.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		valuations, err := s.ListValuations(account.ID)
+		if err != nil {
+			return nil, err
+		}
+		known = make([]Point, 0, len(valuations))
+		for _, valuation := range valuations {
+			known = append(known, Point{Date: valuation.Date, Value: valuation.Value})
+		}
+	}
+
+	return &BalanceHistory{
+		Account:     account,
+		Granularity: granularity,
+		Points:      History(account, initial, known, from, to, granularity),
+	}, nil
+}
+
+// endOfDayBalances returns the balance after the last transaction of every day up to the passed date.
+// Statements list the newest transaction of a day first, so it has the lowest ID of the day.
+// Manual transactions have no bank balance and are skipped.
+func (s *Service) endOfDayBalances(accountID int64, to time.Time) ([]Point, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT ON (booking_date) booking_date, balance
+		FROM transactions
+		WHERE
+			account_id = $1
+		AND
+			NOT manual
+		AND
+			booking_date <= $2
+		ORDER BY booking_date ASC, id ASC;
+	`, accountID, database.NormalizeTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]Point, 0)
+	for rows.Next() {
+		var point Point
+		err = rows.Scan(&point.Date, &point.Value)
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, point)
+	}
+
+	return points, rows.Err()
+}
+
+// balanceBeforeFirstTransaction returns the balance of the account before its oldest
+// transaction was booked, or zero if the account has no transactions.
+func (s *Service) balanceBeforeFirstTransaction(accountID int64) (float64, error) {
+	var balance float64
+	err := s.db.QueryRow(`
+		SELECT balance - amount
+		FROM transactions
+		WHERE account_id = $1 AND NOT manual
+		ORDER BY booking_date ASC, id DESC
+		LIMIT 1;
+	`, accountID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// Reconcile checks the continuity of the balances of all transactions of the bank account
+// booked between from and to. The last transaction before from is included, so a gap
+// directly at the start of the period is found as well.
+func (s *Service) Reconcile(accountID int64, from, to time.Time) (*ReconciliationReport, error) {
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+
+	account, err := s.Get(accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Kind != KindBank {
+		return nil, fmt.Errorf("%w: only bank accounts have balances to reconcile", ErrInvalidReconciliation)
+	}
+
+	entries, err := s.entries(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciled, err := s.ListReconciliations(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	checked := len(entries)
+	if checked > 0 && entries[0].Date.Before(from) {
+		checked--
+	}
+	return &ReconciliationReport{
+		Account:    *account,
+		From:       from,
+		To:         to,
+		Checked:    checked,
+		Issues:     CheckContinuity(entries),
+		Reconciled: reconciled,
+	}, nil
+}
+
+// ListReconciliations returns the reconciled periods of the account overlapping from and to.
+func (s *Service) ListReconciliations(accountID int64, from, to time.Time) ([]Reconciliation, error) {
+	rows, err := s.db.Query(`
+		SELECT id, account_id, start_date, end_date, balance, created_at
+		FROM account_reconciliations
+		WHERE
+			account_id = $1
+		AND
+			start_date <= $3
+		AND
+			end_date >= $2
+		ORDER BY start_date, id;
+	`, accountID, database.NormalizeTime(from), database.NormalizeTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reconciliations := make([]Reconciliation, 0)
+	for rows.Next() {
+		var reconciliation Reconciliation
+		err = rows.Scan(
+			&reconciliation.ID,
+			&reconciliation.AccountID,
+			&reconciliation.From,
+			&reconciliation.To,
+			&reconciliation.Balance,
+			&reconciliation.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reconciliations = append(reconciliations, reconciliation)
+	}
+
+	return reconciliations, rows.Err()
+}
+
+// CreateReconciliation marks the period as reconciled. Periods with inconsistent balances
+// can't be reconciled, the balance stored is the balance at the end of the period.
+func (s *Service) CreateReconciliation(accountID int64, request ReconciliationRequest) (*Reconciliation, error) {
+	from, err := time.Parse(time.DateOnly, request.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReconciliation, err)
+	}
+	to, err := time.Parse(time.DateOnly, request.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReconciliation, err)
+	}
+
+	report, err := s.Reconcile(accountID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Issues) > 0 {
+		return nil, fmt.Errorf("%w: %d issues found", ErrInconsistentBalances, len(report.Issues))
+	}
+
+	balance, err := s.balanceAt(accountID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliation := Reconciliation{
+		AccountID: accountID,
+		From:      from,
+		To:        to,
+		Balance:   balance,
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO account_reconciliations (account_id, start_date, end_date, balance)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`, accountID, from, to, balance).Scan(&reconciliation.ID, &reconciliation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reconciliation, nil
+}
+
+func (s *Service) DeleteReconciliation(accountID, reconciliationID int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM account_reconciliations
+		WHERE id = $1 AND account_id = $2;
+	`, reconciliationID, accountID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrReconciliationNotFound
+	}
+	return nil
+}
+
+// entries returns the imported transactions booked between from and to ordered from the oldest
+// to the newest, preceded by the last transaction booked before from.
+func (s *Service) entries(accountID int64, from, to time.Time) ([]Entry, error) {
+	rows, err := s.db.Query(`
+		(
+			SELECT id, booking_date, amount, balance
+			FROM transactions
+			WHERE
+				account_id = $1
+			AND
+				NOT manual
+			AND
+				booking_date < $2
+			ORDER BY booking_date DESC, id ASC
+			LIMIT 1
+		)
+		UNION ALL
+		(
+			SELECT id, booking_date, amount, balance
+			FROM transactions
+			WHERE
+				account_id = $1
+			AND
+				NOT manual
+			AND
+				booking_date BETWEEN $2 AND $3
+		)
+		ORDER BY booking_date ASC, id DESC;
+	`, accountID, database.NormalizeTime(from), database.NormalizeTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var entry Entry
+		err = rows.Scan(&entry.ID, &entry.Date, &entry.Amount, &entry.Balance)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// balanceAt returns the balance of the bank account at the end of the passed day.
+func (s *Service) balanceAt(accountID int64, date time.Time) (float64, error) {
+	var balance float64
+	err := s.db.QueryRow(`
+		SELECT balance
+		FROM transactions
+		WHERE
+			account_id = $1
+		AND
+			NOT manual
+		AND
+			booking_date <= $2
+		ORDER BY booking_date DESC, id ASC
+		LIMIT 1;
+	`, accountID, database.NormalizeTime(date)).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return s.balanceBeforeFirstTransaction(accountID)
+	}
+	return balance, err
+}
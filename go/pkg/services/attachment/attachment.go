@@ -0,0 +1,61 @@
+package attachment
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Attachment is a file like a receipt or an invoice attached to a transaction.
+type Attachment struct {
+	ID            int64     `json:"id"`
+	TransactionID int64     `json:"transactionID"`
+	FileName      string    `json:"fileName"`
+	ContentType   string    `json:"contentType"`
+	Size          int64     `json:"size"`
+	CreatedAt     time.Time `json:"createdAt"`
+	// blobKey is the key the content is stored with in the blob store.
+	blobKey string
+}
+
+// allowedContentTypes are the media types of receipts, invoices and statements accepted as attachments.
+var allowedContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"text/plain":      true,
+}
+
+// DetectContentType determines the media type of the content from its first bytes. The type
+// passed by the client is not trusted, so files can't be disguised as another type.
+func DetectContentType(head []byte) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContentType, err)
+	}
+	if !allowedContentTypes[mediaType] {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedContentType, mediaType)
+	}
+	return mediaType, nil
+}
+
+// SanitizeFileName returns the base name of the uploaded file without control characters and quotes,
+// so it can be used in the content disposition of downloads.
+func SanitizeFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,54 @@
+package attachment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DetectContentType(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    []byte
+		want    string
+		wantErr error
+	}{
+		{name: "should detect pdfs", head: []byte("%PDF-1.7\n"), want: "application/pdf"},
+		{name: "should detect pngs", head: []byte("\x89PNG\x0D\x0A\x1A\x0A"), want: "image/png"},
+		{name: "should detect jpegs", head: []byte("\xFF\xD8\xFF\xE0"), want: "image/jpeg"},
+		{name: "should strip the charset of text", head: []byte("Rechnung 2024-001"), want: "text/plain"},
+		{name: "should reject html", head: []byte("<html><body>receipt</body></html>"), wantErr: ErrUnsupportedContentType},
+		{name: "should reject binaries", head: []byte("\x7FELF\x02\x01\x01\x00"), wantErr: ErrUnsupportedContentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectContentType(tt.head)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SanitizeFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "receipt.pdf", want: "receipt.pdf"},
+		{name: "C:\\Users\\max\\Rechnung Möbel.pdf", want: "Rechnung Möbel.pdf"},
+		{name: "../../etc/passwd", want: "passwd"},
+		{name: "a\"b\n.png", want: "ab.png"},
+		{name: "", want: "attachment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeFileName(tt.name))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"docqube.de/bookkeeper/pkg/blobstore"
+	"docqube.de/bookkeeper/pkg/config"
+	"docqube.de/bookkeeper/pkg/services/attachment"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead is the size allowed for the multipart envelope of an upload in addition to the file.
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	Service *attachment.Service
+	MaxSize int64
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB, store blobstore.Store, attachmentConfig config.AttachmentConfig) *Handler {
+	handler := &Handler{
+		Service: attachment.NewService(db, store, attachmentConfig.MaxSize),
+		MaxSize: attachmentConfig.MaxSize,
+	}
+
+	transactionAPI := router.Group("/transaction")
+	transactionAPI.GET("/:id/attachments", handler.List)
+	transactionAPI.POST("/:id/attachments", handler.Upload)
+	transactionAPI.GET("/:id/attachments/:attachmentID", handler.Download)
+	transactionAPI.DELETE("/:id/attachments/:attachmentID", handler.Delete)
+
+	return handler
+}
+
+func (h *Handler) List(c *gin.Context) {
+	transactionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	attachments, err := h.Service.List(transactionID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attachments)
+}
+
+// Upload attaches the file of the multipart form field file to the transaction.
+func (h *Handler) Upload(c *gin.Context) {
+	transactionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.MaxSize+multipartOverhead)
+	file, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": attachment.ErrAttachmentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer content.Close()
+
+	created, err := h.Service.Create(transactionID, file.Filename, content, file.Size)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) Download(c *gin.Context) {
+	transactionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("attachmentID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	a, err := h.Service.Get(transactionID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	content, err := h.Service.Open(a)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, a.Size, a.ContentType, content, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", a.FileName),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	transactionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("attachmentID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.Delete(transactionID, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, transaction.ErrTransactionNotFound),
+		errors.Is(err, attachment.ErrAttachmentNotFound),
+		errors.Is(err, blobstore.ErrBlobNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, attachment.ErrInvalidAttachment):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, attachment.ErrAttachmentTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, attachment.ErrUnsupportedContentType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
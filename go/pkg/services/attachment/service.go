@@ -0,0 +1,200 @@
+package attachment
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"docqube.de/bookkeeper/pkg/blobstore"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	ErrAttachmentNotFound     = errors.New("attachment not found")
+	ErrAttachmentTooLarge     = errors.New("attachment too large")
+	ErrUnsupportedContentType = errors.New("unsupported content type")
+	ErrInvalidAttachment      = errors.New("invalid attachment")
+)
+
+// sniffLength is the number of bytes used to detect the content type.
+const sniffLength = 512
+
+type Service struct {
+	db                 *sql.DB
+	store              blobstore.Store
+	maxSize            int64
+	transactionService *transaction.Service
+}
+
+func NewService(db *sql.DB, store blobstore.Store, maxSize int64) *Service {
+	return &Service{
+		db:                 db,
+		store:              store,
+		maxSize:            maxSize,
+		transactionService: transaction.NewService(db),
+	}
+}
+
+const attachmentColumns = `id, transaction_id, file_name, content_type, size, blob_key, created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttachment(row scanner) (*Attachment, error) {
+	var attachment Attachment
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.TransactionID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.blobKey,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// List returns the attachments of the transaction ordered by their upload.
+func (s *Service) List(transactionID int64) ([]Attachment, error) {
+	_, err := s.transactionService.Get(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM transaction_attachments
+		WHERE transaction_id = $1
+		ORDER BY id;
+	`, attachmentColumns), transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := make([]Attachment, 0)
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, *attachment)
+	}
+	return attachments, rows.Err()
+}
+
+func (s *Service) Get(transactionID, id int64) (*Attachment, error) {
+	attachment, err := scanAttachment(s.db.QueryRow(fmt.Sprintf(`
+		SELECT %s
+		FROM transaction_attachments
+		WHERE id = $1 AND transaction_id = $2;
+	`, attachmentColumns), id, transactionID))
+	if err == sql.ErrNoRows {
+		return nil, ErrAttachmentNotFound
+	}
+	return attachment, err
+}
+
+// Open returns the content of the attachment, which has to be closed by the caller.
+func (s *Service) Open(attachment *Attachment) (io.ReadCloser, error) {
+	return s.store.Get(attachment.blobKey)
+}
+
+// Create stores the content in the blob store and attaches it to the transaction. The content
+// type is detected from the content and has to be one of the allowed types.
+func (s *Service) Create(transactionID int64, fileName string, content io.Reader, size int64) (*Attachment, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidAttachment)
+	}
+	if size > s.maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrAttachmentTooLarge, size, s.maxSize)
+	}
+
+	_, err := s.transactionService.Get(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	contentType, err := DetectContentType(head)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := blobKey(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	err = s.store.Put(key, io.MultiReader(bytes.NewReader(head), content), size, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := Attachment{
+		TransactionID: transactionID,
+		FileName:      SanitizeFileName(fileName),
+		ContentType:   contentType,
+		Size:          size,
+		blobKey:       key,
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO transaction_attachments (transaction_id, file_name, content_type, size, blob_key)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at;
+	`, transactionID, attachment.FileName, contentType, size, key).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		// the stored blob is not referenced by any attachment
+		_ = s.store.Delete(key)
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+// Delete deletes the attachment and its content. The content is deleted after the attachment,
+// so an attachment never references missing content. Content, which can't be deleted, is
+// only logged, as the attachment is gone already.
+func (s *Service) Delete(transactionID, id int64) error {
+	attachment, err := s.Get(transactionID, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		DELETE FROM transaction_attachments
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.Delete(attachment.blobKey)
+	if err != nil {
+		log.Warnf("attachment: failed to delete content %s: %s", attachment.blobKey, err)
+	}
+	return nil
+}
+
+// blobKey returns a random key for the content of a new attachment of the transaction.
+func blobKey(transactionID int64) (string, error) {
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("transactions/%d/%s", transactionID, hex.EncodeToString(random)), nil
+}
@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/config"
+)
+
+var ErrInvalidEntityType = errors.New("invalid entity type")
+
+type ActorType string
+
+const (
+	ActorUser       ActorType = "user"
+	ActorRuleEngine ActorType = "rule_engine"
+	ActorClassifier ActorType = "classifier"
+	ActorImport     ActorType = "import"
+	ActorAPIToken   ActorType = "api_token"
+	// ActorSystem is recorded for changes made without an actor, e.g. by migrations.
+	ActorSystem ActorType = "system"
+)
+
+// Actor made a change. The name identifies the user or API token, if known.
+type Actor struct {
+	Type ActorType `json:"type"`
+	Name string    `json:"name,omitempty"`
+}
+
+var (
+	RuleEngine = Actor{Type: ActorRuleEngine}
+	Classifier = Actor{Type: ActorClassifier}
+	Import     = Actor{Type: ActorImport}
+)
+
+type EntityType string
+
+const (
+	EntityTransaction EntityType = "transaction"
+	EntityCategory    EntityType = "category"
+	EntityRule        EntityType = "rule"
+)
+
+func ParseEntityType(value string) (EntityType, error) {
+	switch entityType := EntityType(value); entityType {
+	case EntityTransaction, EntityCategory, EntityRule:
+		return entityType, nil
+	}
+	return "", ErrInvalidEntityType
+}
+
+type Action string
+
+const (
+	ActionInsert Action = "insert"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Entry records a change of an entity. Updates contain only the changed values, inserts only the
+// new and deletes only the old values.
+type Entry struct {
+	ID         int64           `json:"id"`
+	EntityType EntityType      `json:"entityType"`
+	EntityID   int64           `json:"entityID"`
+	Action     Action          `json:"action"`
+	Actor      Actor           `json:"actor"`
+	OldValues  json.RawMessage `json:"oldValues"`
+	NewValues  json.RawMessage `json:"newValues"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// Identifier identifies the actors of requests.
+type Identifier struct {
+	trustedProxies []netip.Prefix
+	userHeader     string
+	apiTokens      map[string]string
+}
+
+func NewIdentifier(auditConfig config.AuditConfig) (*Identifier, error) {
+	identifier := &Identifier{
+		trustedProxies: make([]netip.Prefix, 0, len(auditConfig.TrustedProxies)),
+		userHeader:     auditConfig.UserHeader,
+		apiTokens:      auditConfig.APITokens,
+	}
+	for _, proxy := range auditConfig.TrustedProxies {
+		prefix, err := config.ParseTrustedProxy(proxy)
+		if err != nil {
+			return nil, err
+		}
+		identifier.trustedProxies = append(identifier.trustedProxies, prefix)
+	}
+	return identifier, nil
+}
+
+// Identify returns the actor of the request. Requests passing one of the configured API tokens as
+// bearer token are made by the API token. All other requests are made by the user, who is only
+// named, if a trusted proxy forwarded the request, as clients could set the user header themselves.
+func (i *Identifier) Identify(request *http.Request) Actor {
+	if token, ok := strings.CutPrefix(request.Header.Get("Authorization"), "Bearer "); ok {
+		if name, ok := i.apiToken(strings.TrimSpace(token)); ok {
+			return Actor{Type: ActorAPIToken, Name: name}
+		}
+	}
+
+	if i.trustedProxy(request) {
+		if name := strings.TrimSpace(request.Header.Get(i.userHeader)); name != "" {
+			return Actor{Type: ActorUser, Name: name}
+		}
+	}
+	return Actor{Type: ActorUser}
+}
+
+// apiToken returns the name of the configured API token. All tokens are compared in constant
+// time, so the comparison doesn't reveal how much of a token matched.
+func (i *Identifier) apiToken(token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	var (
+		matched string
+		found   bool
+	)
+	for name, apiToken := range i.apiTokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(apiToken)) == 1 {
+			matched = name
+			found = true
+		}
+	}
+	return matched, found
+}
+
+// trustedProxy returns true, if the request was sent by one of the trusted proxies.
+func (i *Identifier) trustedProxy(request *http.Request) bool {
+	host, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range i.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+type actorKey struct{}
+
+// WithActor returns the request with the identified actor, see ActorFromRequest.
+func WithActor(request *http.Request, actor Actor) *http.Request {
+	return request.WithContext(context.WithValue(request.Context(), actorKey{}, actor))
+}
+
+// ActorFromRequest returns the actor identified for the request or an unnamed user,
+// if the request wasn't identified.
+func ActorFromRequest(request *http.Request) Actor {
+	if actor, ok := request.Context().Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return Actor{Type: ActorUser}
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Identifier_Identify(t *testing.T) {
+	identifier, err := NewIdentifier(config.AuditConfig{
+		TrustedProxies: []string{"10.0.0.0/8", "::1"},
+		UserHeader:     "X-Forwarded-User",
+		APITokens:      map[string]string{"ci": "0123456789abcdef"},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       Actor
+	}{
+		{
+			name:       "should name configured API tokens",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string]string{"Authorization": "Bearer 0123456789abcdef"},
+			want:       Actor{Type: ActorAPIToken, Name: "ci"},
+		},
+		{
+			name:       "should not trust unknown API tokens",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string]string{"Authorization": "Bearer secret"},
+			want:       Actor{Type: ActorUser},
+		},
+		{
+			name:       "should name users forwarded by a trusted proxy",
+			remoteAddr: "10.1.2.3:1234",
+			headers:    map[string]string{"X-Forwarded-User": " bob "},
+			want:       Actor{Type: ActorUser, Name: "bob"},
+		},
+		{
+			name:       "should name users forwarded by a trusted IPv6 proxy",
+			remoteAddr: "[::1]:1234",
+			headers:    map[string]string{"X-Forwarded-User": "bob"},
+			want:       Actor{Type: ActorUser, Name: "bob"},
+		},
+		{
+			name:       "should ignore the user header of other clients",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string]string{"X-Forwarded-User": "bob", "Authorization": "Bearer 0123"},
+			want:       Actor{Type: ActorUser},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/", nil)
+			request.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				request.Header.Set(key, value)
+			}
+			assert.Equal(t, tt.want, identifier.Identify(request))
+		})
+	}
+}
+
+func Test_ActorFromRequest(t *testing.T) {
+	request := httptest.NewRequest("GET", "/", nil)
+	assert.Equal(t, Actor{Type: ActorUser}, ActorFromRequest(request))
+
+	actor := Actor{Type: ActorAPIToken, Name: "ci"}
+	assert.Equal(t, actor, ActorFromRequest(WithActor(request, actor)))
+}
+
+func Test_ParseEntityType(t *testing.T) {
+	got, err := ParseEntityType("rule")
+	assert.NoError(t, err)
+	assert.Equal(t, EntityRule, got)
+
+	_, err = ParseEntityType("payee")
+	assert.ErrorIs(t, err, ErrInvalidEntityType)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *audit.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service: audit.NewService(db),
+	}
+
+	auditAPI := router.Group("/audit")
+	auditAPI.GET("/:entityType/:id", handler.History)
+
+	return handler
+}
+
+// Middleware identifies the actor of every request, whose changes are recorded in the audit log.
+func Middleware(identifier *audit.Identifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = audit.WithActor(c.Request, identifier.Identify(c.Request))
+		c.Next()
+	}
+}
+
+// History returns the recorded changes of a transaction, category or rule.
+func (h *Handler) History(c *gin.Context) {
+	entityType, err := audit.ParseEntityType(c.Param("entityType"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.Service.History(entityType, id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, audit.ErrInvalidEntityType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"database/sql"
+)
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Begin starts a database transaction, whose changes of transactions, categories and rules are
+// recorded for the actor. The changes are recorded by triggers, so changes made without an actor
+// are recorded for the system.
+func Begin(db *sql.DB, actor Actor) (*sql.Tx, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		SELECT set_config('bookkeeper.actor_type', $1, true), set_config('bookkeeper.actor_name', $2, true);
+	`, string(actor.Type), actor.Name)
+	if err != nil {
+		_ = tx.Rollback()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// Exec executes a single statement recording its changes for the actor.
+func Exec(db *sql.DB, actor Actor, query string, args ...any) (sql.Result, error) {
+	tx, err := Begin(db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// History returns all recorded changes of the entity from the oldest to the newest.
+func (s *Service) History(entityType EntityType, entityID int64) ([]Entry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, entity_type, entity_id, action, actor_type, COALESCE(actor_name, ''), old_values, new_values, created_at
+		FROM audit_log
+		WHERE entity_type = $1 AND entity_id = $2
+		ORDER BY id;
+	`, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var entry Entry
+		var oldValues, newValues []byte
+		err = rows.Scan(
+			&entry.ID,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Action,
+			&entry.Actor.Type,
+			&entry.Actor.Name,
+			&oldValues,
+			&newValues,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.OldValues = oldValues
+		entry.NewValues = newValues
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,125 @@
+package budget
+
+import (
+	"slices"
+	"sort"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+)
+
+// BudgetTemplate is the recurring amount planned for a category in every fiscal month.
+type BudgetTemplate struct {
+	CategoryID int64   `json:"categoryID"`
+	Amount     float64 `json:"amount"`
+}
+
+// Budget is the amount planned for a category in a fiscal month. Recurring budgets are
+// derived from the template of the category and have not been overridden for the month.
+type Budget struct {
+	CategoryID int64   `json:"categoryID"`
+	Year       int     `json:"year"`
+	Month      int     `json:"month"`
+	Amount     float64 `json:"amount"`
+	Recurring  bool    `json:"recurring"`
+}
+
+type BudgetSetRequest struct {
+	Amount *float64 `json:"amount"`
+}
+
+// BudgetProgress compares the planned amount of a category with the actually
+// spent amount. Planned and actual amounts of expenses are positive.
+type BudgetProgress struct {
+	Category    category.Category `json:"category"`
+	Planned     float64           `json:"planned"`
+	Actual      float64           `json:"actual"`
+	Remaining   float64           `json:"remaining"`
+	PercentUsed *float64          `json:"percentUsed"`
+}
+
+type BudgetProgressReport struct {
+	FiscalMonth interval.FiscalMonth `json:"fiscalMonth"`
+	Items       []BudgetProgress     `json:"items"`
+	Planned     float64              `json:"planned"`
+	Actual      float64              `json:"actual"`
+	Remaining   float64              `json:"remaining"`
+}
+
+// ResolveBudgets returns the budget of every category with a template or an override
+// for the passed fiscal month. Overrides take precedence over the templates.
+func ResolveBudgets(month, year int, templates []BudgetTemplate, overrides []Budget) []Budget {
+	budgets := make(map[int64]Budget)
+	for _, template := range templates {
+		budgets[template.CategoryID] = Budget{
+			CategoryID: template.CategoryID,
+			Year:       year,
+			Month:      month,
+			Amount:     template.Amount,
+			Recurring:  true,
+		}
+	}
+	for _, override := range overrides {
+		if override.Month != month || override.Year != year {
+			continue
+		}
+		override.Recurring = false
+		budgets[override.CategoryID] = override
+	}
+
+	resolved := make([]Budget, 0, len(budgets))
+	for _, budget := range budgets {
+		resolved = append(resolved, budget)
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return resolved[i].CategoryID < resolved[j].CategoryID
+	})
+	return resolved
+}
+
+// CalculateProgress combines the budgets with the sums of the categories. Every expense
+// category with a budget or with spent money is part of the result. Income and transfer
+// categories aren't spent, so they are excluded like unclassified transactions.
+func CalculateProgress(categories []category.Category, budgets []Budget, sums []transaction.CategorySum, incomeCategoryIDs []int64) []BudgetProgress {
+	planned := make(map[int64]float64)
+	for _, budget := range budgets {
+		planned[budget.CategoryID] = budget.Amount
+	}
+
+	actual := make(map[int64]float64)
+	for _, sum := range sums {
+		if sum.CategoryID == nil {
+			continue
+		}
+		// expenses are negative, but are reported as spent amount
+		actual[*sum.CategoryID] = -sum.Sum
+	}
+
+	progress := make([]BudgetProgress, 0)
+	for _, c := range categories {
+		if c.Transfer || slices.Contains(incomeCategoryIDs, c.ID) {
+			continue
+		}
+		plannedAmount, hasBudget := planned[c.ID]
+		actualAmount := actual[c.ID]
+		if !hasBudget && actualAmount <= 0 {
+			continue
+		}
+
+		c.Rules = nil
+		p := BudgetProgress{
+			Category:  c,
+			Planned:   plannedAmount,
+			Actual:    actualAmount,
+			Remaining: plannedAmount - actualAmount,
+		}
+		if plannedAmount != 0 {
+			percentUsed := actualAmount / plannedAmount * 100
+			p.PercentUsed = &percentUsed
+		}
+		progress = append(progress, p)
+	}
+
+	return progress
+}
@@ -0,0 +1,147 @@
+package budget
+
+import (
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ResolveBudgets(t *testing.T) {
+	templates := []BudgetTemplate{
+		{CategoryID: 1, Amount: 400},
+		{CategoryID: 2, Amount: 50},
+	}
+	overrides := []Budget{
+		{CategoryID: 2, Year: 2024, Month: 5, Amount: 120},
+		{CategoryID: 3, Year: 2024, Month: 5, Amount: 30},
+		{CategoryID: 1, Year: 2024, Month: 6, Amount: 1000},
+	}
+
+	tests := []struct {
+		name  string
+		month int
+		year  int
+		want  []Budget
+	}{
+		{
+			name:  "should prefer overrides over templates",
+			month: 5,
+			year:  2024,
+			want: []Budget{
+				{CategoryID: 1, Year: 2024, Month: 5, Amount: 400, Recurring: true},
+				{CategoryID: 2, Year: 2024, Month: 5, Amount: 120},
+				{CategoryID: 3, Year: 2024, Month: 5, Amount: 30},
+			},
+		},
+		{
+			name:  "should fall back to templates",
+			month: 7,
+			year:  2024,
+			want: []Budget{
+				{CategoryID: 1, Year: 2024, Month: 7, Amount: 400, Recurring: true},
+				{CategoryID: 2, Year: 2024, Month: 7, Amount: 50, Recurring: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ResolveBudgets(tt.month, tt.year, templates, overrides))
+		})
+	}
+}
+
+func Test_CalculateProgress(t *testing.T) {
+	categories := []category.Category{
+		{ID: 1, Name: "Groceries"},
+		{ID: 2, Name: "Mobility"},
+		{ID: 3, Name: "Hobbies"},
+		{ID: 4, Name: "Insurance"},
+	}
+	budgets := []Budget{
+		{CategoryID: 1, Amount: 400},
+		{CategoryID: 2, Amount: 100},
+	}
+	sums := []transaction.CategorySum{
+		{CategoryID: utils.NewInt64(1), Count: 12, Sum: -300},
+		{CategoryID: utils.NewInt64(3), Count: 1, Sum: -25.5},
+		{CategoryID: nil, Count: 3, Sum: -80},
+	}
+
+	got := CalculateProgress(categories, budgets, sums, nil)
+	assert.Equal(t, []BudgetProgress{
+		{Category: categories[0], Planned: 400, Actual: 300, Remaining: 100, PercentUsed: utils.NewFloat64(75)},
+		{Category: categories[1], Planned: 100, Actual: 0, Remaining: 100, PercentUsed: utils.NewFloat64(0)},
+		{Category: categories[2], Planned: 0, Actual: 25.5, Remaining: -25.5},
+	}, got)
+}
+
+func Test_CalculateProgress_ExcludesIncomeAndTransfers(t *testing.T) {
+	categories := []category.Category{
+		{ID: 1, Name: "Groceries"},
+		{ID: 2, Name: "Salary"},
+		{ID: 3, Name: "Savings", Transfer: true},
+		{ID: 4, Name: "Refunds"},
+	}
+	budgets := []Budget{
+		{CategoryID: 1, Amount: 400},
+		{CategoryID: 3, Amount: 500},
+	}
+	sums := []transaction.CategorySum{
+		{CategoryID: utils.NewInt64(1), Count: 12, Sum: -300},
+		{CategoryID: utils.NewInt64(2), Count: 1, Sum: 3000},
+		{CategoryID: utils.NewInt64(3), Count: 1, Sum: -500},
+		// categories without budget, which received more than was spent, are no expenses
+		{CategoryID: utils.NewInt64(4), Count: 2, Sum: 40},
+	}
+
+	got := CalculateProgress(categories, budgets, sums, []int64{2})
+	assert.Equal(t, []BudgetProgress{
+		{Category: categories[0], Planned: 400, Actual: 300, Remaining: 100, PercentUsed: utils.NewFloat64(75)},
+	}, got)
+}
+
+func Test_CalculateEnvelopeHistory(t *testing.T) {
+	envelope := Envelope{CategoryID: 1, StartYear: 2024, StartMonth: 1}
+	periods := []EnvelopePeriod{
+		{
+			Year:    2023,
+			Month:   12,
+			Budgets: []Budget{{CategoryID: 1, Amount: 100}},
+			Spent:   map[int64]float64{1: 20},
+		},
+		{
+			Year:    2024,
+			Month:   1,
+			Budgets: []Budget{{CategoryID: 1, Amount: 100}, {CategoryID: 2, Amount: 50}},
+			Spent:   map[int64]float64{1: 30, 2: 10},
+			Movements: []EnvelopeMovement{
+				{FromCategoryID: nil, ToCategoryID: 1, Amount: 200},
+				{FromCategoryID: utils.NewInt64(1), ToCategoryID: 2, Amount: 40},
+			},
+		},
+		{
+			Year:    2024,
+			Month:   2,
+			Budgets: []Budget{{CategoryID: 1, Amount: 100}},
+			Spent:   map[int64]float64{1: 500},
+			Movements: []EnvelopeMovement{
+				{FromCategoryID: utils.NewInt64(2), ToCategoryID: 1, Amount: 15},
+			},
+		},
+		{
+			Year:  2024,
+			Month: 3,
+			Spent: map[int64]float64{},
+		},
+	}
+
+	assert.Equal(t, []EnvelopeMonth{
+		{Year: 2024, Month: 1, CarriedOver: 0, Budgeted: 100, Assigned: 200, MovedOut: 40, Spent: 30, Balance: 230},
+		{Year: 2024, Month: 2, CarriedOver: 230, Budgeted: 100, MovedIn: 15, Spent: 500, Balance: -155},
+		{Year: 2024, Month: 3, CarriedOver: -155, Balance: -155},
+	}, CalculateEnvelopeHistory(envelope, periods))
+}
@@ -0,0 +1,147 @@
+package budget
+
+import (
+	"time"
+)
+
+// Envelope is a category whose unspent or overspent budget rolls over into
+// the next fiscal month, starting with the passed fiscal month.
+type Envelope struct {
+	CategoryID int64 `json:"categoryID"`
+	StartYear  int   `json:"startYear"`
+	StartMonth int   `json:"startMonth"`
+}
+
+type EnvelopeCreateRequest struct {
+	CategoryID int64 `json:"categoryID"`
+	Year       int   `json:"year"`
+	Month      int   `json:"month"`
+}
+
+// EnvelopeMovement moves money into an envelope in a fiscal month. The money is either
+// taken from another envelope or, if no source envelope is set, assigned from income.
+type EnvelopeMovement struct {
+	ID             int64     `json:"id"`
+	Year           int       `json:"year"`
+	Month          int       `json:"month"`
+	FromCategoryID *int64    `json:"fromCategoryID"`
+	ToCategoryID   int64     `json:"toCategoryID"`
+	TransactionID  *int64    `json:"transactionID"`
+	Amount         float64   `json:"amount"`
+	Description    *string   `json:"description"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type EnvelopeMoveRequest struct {
+	Year           int     `json:"year"`
+	Month          int     `json:"month"`
+	FromCategoryID int64   `json:"fromCategoryID"`
+	ToCategoryID   int64   `json:"toCategoryID"`
+	Amount         float64 `json:"amount"`
+	Description    *string `json:"description"`
+}
+
+type EnvelopeAssignRequest struct {
+	Year          int     `json:"year"`
+	Month         int     `json:"month"`
+	ToCategoryID  int64   `json:"toCategoryID"`
+	TransactionID *int64  `json:"transactionID"`
+	Amount        float64 `json:"amount"`
+	Description   *string `json:"description"`
+}
+
+// EnvelopeMonth is the state of an envelope at the end of a fiscal month. The balance is
+// the carried over balance plus the budgeted, assigned and moved money minus the spent money.
+type EnvelopeMonth struct {
+	Year        int       `json:"year"`
+	Month       int       `json:"month"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	CarriedOver float64   `json:"carriedOver"`
+	Budgeted    float64   `json:"budgeted"`
+	Assigned    float64   `json:"assigned"`
+	MovedIn     float64   `json:"movedIn"`
+	MovedOut    float64   `json:"movedOut"`
+	Spent       float64   `json:"spent"`
+	Balance     float64   `json:"balance"`
+}
+
+type EnvelopeHistory struct {
+	Envelope  Envelope           `json:"envelope"`
+	Months    []EnvelopeMonth    `json:"months"`
+	Movements []EnvelopeMovement `json:"movements"`
+}
+
+type EnvelopeBalance struct {
+	Envelope Envelope      `json:"envelope"`
+	Month    EnvelopeMonth `json:"month"`
+}
+
+// EnvelopePeriod contains everything booked within a fiscal month,
+// which is required to calculate the envelope balances.
+type EnvelopePeriod struct {
+	Year      int
+	Month     int
+	Start     time.Time
+	End       time.Time
+	Budgets   []Budget
+	Spent     map[int64]float64
+	Movements []EnvelopeMovement
+}
+
+// CalculateEnvelopeHistory calculates the state of the envelope for every passed fiscal
+// month, which have to be ordered ascending. Fiscal months before the start of the
+// envelope are skipped.
+func CalculateEnvelopeHistory(envelope Envelope, periods []EnvelopePeriod) []EnvelopeMonth {
+	months := make([]EnvelopeMonth, 0, len(periods))
+
+	var balance float64
+	for _, period := range periods {
+		if compareMonths(period.Month, period.Year, envelope.StartMonth, envelope.StartYear) < 0 {
+			continue
+		}
+
+		month := EnvelopeMonth{
+			Year:        period.Year,
+			Month:       period.Month,
+			Start:       period.Start,
+			End:         period.End,
+			CarriedOver: balance,
+			Spent:       period.Spent[envelope.CategoryID],
+		}
+		for _, budget := range period.Budgets {
+			if budget.CategoryID == envelope.CategoryID {
+				month.Budgeted = budget.Amount
+			}
+		}
+		for _, movement := range period.Movements {
+			switch {
+			case movement.ToCategoryID == envelope.CategoryID && movement.FromCategoryID == nil:
+				month.Assigned += movement.Amount
+			case movement.ToCategoryID == envelope.CategoryID:
+				month.MovedIn += movement.Amount
+			case movement.FromCategoryID != nil && *movement.FromCategoryID == envelope.CategoryID:
+				month.MovedOut += movement.Amount
+			}
+		}
+
+		month.Balance = month.CarriedOver + month.Budgeted + month.Assigned + month.MovedIn - month.MovedOut - month.Spent
+		balance = month.Balance
+		months = append(months, month)
+	}
+
+	return months
+}
+
+// compareMonths returns a negative number, if the first month is before the
+// second month, zero if both are the same and a positive number otherwise.
+func compareMonths(month, year, otherMonth, otherYear int) int {
+	return (year*12 + month) - (otherYear*12 + otherMonth)
+}
+
+func nextMonth(month, year int) (int, int) {
+	if month == 12 {
+		return 1, year + 1
+	}
+	return month + 1, year
+}
@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"docqube.de/bookkeeper/pkg/services/budget"
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *budget.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service: budget.NewService(db),
+	}
+
+	budgetsAPI := router.Group("/budgets")
+	budgetsAPI.GET("", handler.List)
+	budgetsAPI.GET("/templates", handler.ListTemplates)
+	budgetsAPI.GET("/progress", handler.Progress)
+
+	budgetAPI := router.Group("/budget")
+	budgetAPI.PUT("/:categoryID", handler.Set)
+	budgetAPI.DELETE("/:categoryID", handler.Delete)
+	budgetAPI.PUT("/:categoryID/template", handler.SetTemplate)
+	budgetAPI.DELETE("/:categoryID/template", handler.DeleteTemplate)
+
+	envelopesAPI := router.Group("/envelopes")
+	envelopesAPI.GET("", handler.ListEnvelopes)
+	envelopesAPI.POST("", handler.CreateEnvelope)
+	envelopesAPI.GET("/balances", handler.Balances)
+	envelopesAPI.POST("/moves", handler.Move)
+	envelopesAPI.POST("/assignments", handler.Assign)
+	envelopesAPI.DELETE("/movements/:id", handler.DeleteMovement)
+
+	envelopeAPI := router.Group("/envelope")
+	envelopeAPI.DELETE("/:categoryID", handler.DeleteEnvelope)
+	envelopeAPI.GET("/:categoryID/history", handler.History)
+
+	return handler
+}
+
+func (h *Handler) List(c *gin.Context) {
+	month, year, err := parseFiscalMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budgets, err := h.Service.List(month, year)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+func (h *Handler) ListTemplates(c *gin.Context) {
+	templates, err := h.Service.ListTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *Handler) Progress(c *gin.Context) {
+	month, year, err := parseFiscalMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the income category overrides the configured fiscal settings
+	var incomeCategoryID int64
+	if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+		incomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	report, err := h.Service.Progress(month, year, incomeCategoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) Set(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("categoryID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	month, year, err := parseFiscalMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := bindAmount(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Service.Set(month, year, categoryID, amount)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("categoryID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	month, year, err := parseFiscalMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.Delete(month, year, categoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) SetTemplate(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("categoryID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	amount, err := bindAmount(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.Service.SetTemplate(categoryID, amount)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+func (h *Handler) DeleteTemplate(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("categoryID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteTemplate(categoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) ListEnvelopes(c *gin.Context) {
+	envelopes, err := h.Service.ListEnvelopes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, envelopes)
+}
+
+func (h *Handler) CreateEnvelope(c *gin.Context) {
+	var createRequest budget.EnvelopeCreateRequest
+	err := c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	envelope, err := h.Service.CreateEnvelope(createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, envelope)
+}
+
+func (h *Handler) DeleteEnvelope(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("categoryID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteEnvelope(categoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) Balances(c *gin.Context) {
+	month, year, err := parseFiscalMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the income category overrides the configured fiscal settings
+	var incomeCategoryID int64
+	if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+		incomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	balances, err := h.Service.Balances(month, year, incomeCategoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, balances)
+}
+
+func (h *Handler) History(c *gin.Context) {
+	categoryID, err := strconv.ParseInt(c.Param("categoryID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	month, year, err := parseFiscalMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the income category overrides the configured fiscal settings
+	var incomeCategoryID int64
+	if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+		incomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	history, err := h.Service.History(categoryID, month, year, incomeCategoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+func (h *Handler) Move(c *gin.Context) {
+	var moveRequest budget.EnvelopeMoveRequest
+	err := c.BindJSON(&moveRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movement, err := h.Service.Move(moveRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, movement)
+}
+
+func (h *Handler) Assign(c *gin.Context) {
+	var assignRequest budget.EnvelopeAssignRequest
+	err := c.BindJSON(&assignRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movement, err := h.Service.Assign(assignRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, movement)
+}
+
+func (h *Handler) DeleteMovement(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteMovement(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func parseFiscalMonth(c *gin.Context) (int, int, error) {
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	month, err := strconv.Atoi(c.Query("month"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return month, year, nil
+}
+
+func bindAmount(c *gin.Context) (float64, error) {
+	var setRequest budget.BudgetSetRequest
+	err := c.BindJSON(&setRequest)
+	if err != nil {
+		return 0, err
+	}
+	if setRequest.Amount == nil {
+		return 0, budget.ErrAmountMissing
+	}
+	return *setRequest.Amount, nil
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, budget.ErrBudgetNotFound),
+		errors.Is(err, budget.ErrEnvelopeNotFound),
+		errors.Is(err, budget.ErrMovementNotFound),
+		errors.Is(err, category.ErrCategoryNotFound),
+		errors.Is(err, transaction.ErrTransactionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, budget.ErrInvalidMonth),
+		errors.Is(err, budget.ErrInvalidMovement),
+		errors.Is(err, budget.ErrMonthBeforeEnvelope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, budget.ErrIncomeOverassigned):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,645 @@
+package budget
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+)
+
+var (
+	ErrBudgetNotFound      = errors.New("budget not found")
+	ErrAmountMissing       = errors.New("budget amount missing")
+	ErrInvalidMonth        = errors.New("month must be between 1 and 12")
+	ErrEnvelopeNotFound    = errors.New("envelope not found")
+	ErrMovementNotFound    = errors.New("envelope movement not found")
+	ErrInvalidMovement     = errors.New("invalid envelope movement")
+	ErrIncomeOverassigned  = errors.New("income is already assigned completely")
+	ErrMonthBeforeEnvelope = errors.New("month is before the start of the envelope")
+)
+
+type Service struct {
+	db                 *sql.DB
+	categoryService    *category.Service
+	intervalService    *interval.Service
+	transactionService *transaction.Service
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db:                 db,
+		categoryService:    category.NewService(db),
+		intervalService:    interval.NewService(db),
+		transactionService: transaction.NewService(db),
+	}
+}
+
+func (s *Service) ListTemplates() ([]BudgetTemplate, error) {
+	rows, err := s.db.Query(`
+		SELECT category_id, amount
+		FROM budget_templates
+		ORDER BY category_id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := make([]BudgetTemplate, 0)
+	for rows.Next() {
+		var template BudgetTemplate
+		err = rows.Scan(&template.CategoryID, &template.Amount)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+
+	return templates, rows.Err()
+}
+
+// SetTemplate sets the amount planned for the category in every
+// fiscal month, which has not been overridden.
+func (s *Service) SetTemplate(categoryID int64, amount float64) (*BudgetTemplate, error) {
+	_, err := s.categoryService.Get(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO budget_templates (category_id, amount)
+		VALUES ($1, $2)
+		ON CONFLICT (category_id) DO UPDATE
+		SET amount = EXCLUDED.amount;
+	`, categoryID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BudgetTemplate{CategoryID: categoryID, Amount: amount}, nil
+}
+
+func (s *Service) DeleteTemplate(categoryID int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM budget_templates
+		WHERE category_id = $1;
+	`, categoryID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// List returns the budgets of all categories for the passed fiscal month.
+func (s *Service) List(month, year int) ([]Budget, error) {
+	if month < 1 || month > 12 {
+		return nil, ErrInvalidMonth
+	}
+
+	templates, err := s.ListTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT category_id, year, month, amount
+		FROM budgets
+		WHERE year = $1 AND month = $2;
+	`, year, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make([]Budget, 0)
+	for rows.Next() {
+		var budget Budget
+		err = rows.Scan(&budget.CategoryID, &budget.Year, &budget.Month, &budget.Amount)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, budget)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return ResolveBudgets(month, year, templates, overrides), nil
+}
+
+// Set overrides the amount planned for the category in the passed fiscal month.
+func (s *Service) Set(month, year int, categoryID int64, amount float64) (*Budget, error) {
+	if month < 1 || month > 12 {
+		return nil, ErrInvalidMonth
+	}
+
+	_, err := s.categoryService.Get(categoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO budgets (category_id, year, month, amount)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (category_id, year, month) DO UPDATE
+		SET amount = EXCLUDED.amount;
+	`, categoryID, year, month, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Budget{
+		CategoryID: categoryID,
+		Year:       year,
+		Month:      month,
+		Amount:     amount,
+	}, nil
+}
+
+// Delete removes the override of the passed fiscal month,
+// so the template of the category applies again.
+func (s *Service) Delete(month, year int, categoryID int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM budgets
+		WHERE category_id = $1 AND year = $2 AND month = $3;
+	`, categoryID, year, month)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+// Progress compares the budgets of the passed fiscal month with the sums of the
+// transactions booked within the boundaries of the fiscal month.
+func (s *Service) Progress(month, year int, incomeCategoryID int64) (*BudgetProgressReport, error) {
+	budgets, err := s.List(month, year)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := s.intervalService.GetFiscalMonthWithIncomeCategoryID(month, year, incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := s.transactionService.SumByCategory(*start, *end)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryService.List(true)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := s.intervalService.FiscalSettings()
+	if err != nil {
+		return nil, err
+	}
+	incomeCategoryIDs := settings.IncomeCategoryIDs
+	if incomeCategoryID != 0 {
+		incomeCategoryIDs = append(incomeCategoryIDs, incomeCategoryID)
+	}
+
+	report := BudgetProgressReport{
+		FiscalMonth: interval.FiscalMonth{
+			Month: month,
+			Year:  year,
+			Start: *start,
+			End:   *end,
+		},
+		Items: CalculateProgress(categories, budgets, sums, incomeCategoryIDs),
+	}
+	for _, item := range report.Items {
+		report.Planned += item.Planned
+		report.Actual += item.Actual
+		report.Remaining += item.Remaining
+	}
+
+	return &report, nil
+}
+
+func (s *Service) ListEnvelopes() ([]Envelope, error) {
+	rows, err := s.db.Query(`
+		SELECT category_id, start_year, start_month
+		FROM envelopes
+		ORDER BY category_id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	envelopes := make([]Envelope, 0)
+	for rows.Next() {
+		var envelope Envelope
+		err = rows.Scan(&envelope.CategoryID, &envelope.StartYear, &envelope.StartMonth)
+		if err != nil {
+			return nil, err
+		}
+		envelopes = append(envelopes, envelope)
+	}
+
+	return envelopes, rows.Err()
+}
+
+func (s *Service) GetEnvelope(categoryID int64) (*Envelope, error) {
+	var envelope Envelope
+	err := s.db.QueryRow(`
+		SELECT category_id, start_year, start_month
+		FROM envelopes
+		WHERE category_id = $1;
+	`, categoryID).Scan(&envelope.CategoryID, &envelope.StartYear, &envelope.StartMonth)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEnvelopeNotFound
+		}
+		return nil, err
+	}
+	return &envelope, nil
+}
+
+// CreateEnvelope turns the budget of the category into an envelope starting with the
+// passed fiscal month. Creating an existing envelope again moves its start.
+func (s *Service) CreateEnvelope(request EnvelopeCreateRequest) (*Envelope, error) {
+	if request.Month < 1 || request.Month > 12 {
+		return nil, ErrInvalidMonth
+	}
+
+	_, err := s.categoryService.Get(request.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO envelopes (category_id, start_year, start_month)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (category_id) DO UPDATE
+		SET start_year = EXCLUDED.start_year, start_month = EXCLUDED.start_month;
+	`, request.CategoryID, request.Year, request.Month)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		CategoryID: request.CategoryID,
+		StartYear:  request.Year,
+		StartMonth: request.Month,
+	}, nil
+}
+
+// DeleteEnvelope turns the envelope back into a simple budget
+// and deletes all movements from and into the envelope.
+func (s *Service) DeleteEnvelope(categoryID int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DELETE FROM envelope_movements
+		WHERE from_category_id = $1 OR to_category_id = $1;
+	`, categoryID)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM envelopes
+		WHERE category_id = $1;
+	`, categoryID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrEnvelopeNotFound
+	}
+
+	return tx.Commit()
+}
+
+// Move moves money from one envelope into another in the passed fiscal month.
+func (s *Service) Move(request EnvelopeMoveRequest) (*EnvelopeMovement, error) {
+	if request.Month < 1 || request.Month > 12 {
+		return nil, ErrInvalidMonth
+	}
+	if request.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidMovement)
+	}
+	if request.FromCategoryID == request.ToCategoryID {
+		return nil, fmt.Errorf("%w: source and target envelope are the same", ErrInvalidMovement)
+	}
+
+	for _, categoryID := range []int64{request.FromCategoryID, request.ToCategoryID} {
+		err := s.checkEnvelopeMonth(categoryID, request.Month, request.Year)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s.createMovement(EnvelopeMovement{
+		Year:           request.Year,
+		Month:          request.Month,
+		FromCategoryID: &request.FromCategoryID,
+		ToCategoryID:   request.ToCategoryID,
+		Amount:         request.Amount,
+		Description:    request.Description,
+	})
+}
+
+// Assign assigns income to an envelope in the passed fiscal month. If a transaction is
+// passed, the sum of all assignments of the transaction must not exceed its amount.
+func (s *Service) Assign(request EnvelopeAssignRequest) (*EnvelopeMovement, error) {
+	if request.Month < 1 || request.Month > 12 {
+		return nil, ErrInvalidMonth
+	}
+	if request.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidMovement)
+	}
+
+	err := s.checkEnvelopeMonth(request.ToCategoryID, request.Month, request.Year)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.TransactionID != nil {
+		t, err := s.transactionService.Get(*request.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if t.Amount <= 0 {
+			return nil, fmt.Errorf("%w: transaction is no income", ErrInvalidMovement)
+		}
+
+		var assigned float64
+		err = s.db.QueryRow(`
+			SELECT COALESCE(SUM(amount), 0)
+			FROM envelope_movements
+			WHERE transaction_id = $1;
+		`, t.ID).Scan(&assigned)
+		if err != nil {
+			return nil, err
+		}
+		if assigned+request.Amount > t.Amount {
+			return nil, fmt.Errorf("%w: %.2f of %.2f left", ErrIncomeOverassigned, t.Amount-assigned, t.Amount)
+		}
+	}
+
+	return s.createMovement(EnvelopeMovement{
+		Year:          request.Year,
+		Month:         request.Month,
+		ToCategoryID:  request.ToCategoryID,
+		TransactionID: request.TransactionID,
+		Amount:        request.Amount,
+		Description:   request.Description,
+	})
+}
+
+func (s *Service) DeleteMovement(id int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM envelope_movements
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrMovementNotFound
+	}
+	return nil
+}
+
+// History returns the state of the envelope in every fiscal month from its
+// start up to the passed fiscal month and all of its movements.
+func (s *Service) History(categoryID int64, month, year int, incomeCategoryID int64) (*EnvelopeHistory, error) {
+	envelope, err := s.GetEnvelope(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	if compareMonths(month, year, envelope.StartMonth, envelope.StartYear) < 0 {
+		return nil, ErrMonthBeforeEnvelope
+	}
+
+	periods, err := s.envelopePeriods(envelope.StartMonth, envelope.StartYear, month, year, incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := EnvelopeHistory{
+		Envelope:  *envelope,
+		Months:    CalculateEnvelopeHistory(*envelope, periods),
+		Movements: make([]EnvelopeMovement, 0),
+	}
+	for _, period := range periods {
+		for _, movement := range period.Movements {
+			if movement.ToCategoryID == categoryID || movement.FromCategoryID != nil && *movement.FromCategoryID == categoryID {
+				history.Movements = append(history.Movements, movement)
+			}
+		}
+	}
+
+	return &history, nil
+}
+
+// Balances returns the state of every envelope in the passed fiscal month.
+// Envelopes starting after the passed fiscal month are skipped.
+func (s *Service) Balances(month, year int, incomeCategoryID int64) ([]EnvelopeBalance, error) {
+	if month < 1 || month > 12 {
+		return nil, ErrInvalidMonth
+	}
+
+	envelopes, err := s.ListEnvelopes()
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]EnvelopeBalance, 0)
+	if len(envelopes) == 0 {
+		return balances, nil
+	}
+
+	// all envelopes share the fiscal months, so they are only loaded once from the earliest start
+	startMonth, startYear := envelopes[0].StartMonth, envelopes[0].StartYear
+	for _, envelope := range envelopes {
+		if compareMonths(envelope.StartMonth, envelope.StartYear, startMonth, startYear) < 0 {
+			startMonth, startYear = envelope.StartMonth, envelope.StartYear
+		}
+	}
+	if compareMonths(month, year, startMonth, startYear) < 0 {
+		return balances, nil
+	}
+
+	periods, err := s.envelopePeriods(startMonth, startYear, month, year, incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, envelope := range envelopes {
+		months := CalculateEnvelopeHistory(envelope, periods)
+		if len(months) == 0 {
+			continue
+		}
+		balances = append(balances, EnvelopeBalance{
+			Envelope: envelope,
+			Month:    months[len(months)-1],
+		})
+	}
+
+	return balances, nil
+}
+
+func (s *Service) checkEnvelopeMonth(categoryID int64, month, year int) error {
+	envelope, err := s.GetEnvelope(categoryID)
+	if err != nil {
+		return err
+	}
+	if compareMonths(month, year, envelope.StartMonth, envelope.StartYear) < 0 {
+		return ErrMonthBeforeEnvelope
+	}
+	return nil
+}
+
+func (s *Service) createMovement(movement EnvelopeMovement) (*EnvelopeMovement, error) {
+	err := s.db.QueryRow(`
+		INSERT INTO envelope_movements (year, month, from_category_id, to_category_id, transaction_id, amount, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at;
+	`,
+		movement.Year,
+		movement.Month,
+		movement.FromCategoryID,
+		movement.ToCategoryID,
+		movement.TransactionID,
+		movement.Amount,
+		movement.Description,
+	).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+func (s *Service) listMovements(month, year int) ([]EnvelopeMovement, error) {
+	rows, err := s.db.Query(`
+		SELECT id, year, month, from_category_id, to_category_id, transaction_id, amount, description, created_at
+		FROM envelope_movements
+		WHERE year = $1 AND month = $2
+		ORDER BY id;
+	`, year, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := make([]EnvelopeMovement, 0)
+	for rows.Next() {
+		var (
+			movement       EnvelopeMovement
+			fromCategoryID sql.NullInt64
+			transactionID  sql.NullInt64
+			description    sql.NullString
+		)
+		err = rows.Scan(
+			&movement.ID,
+			&movement.Year,
+			&movement.Month,
+			&fromCategoryID,
+			&movement.ToCategoryID,
+			&transactionID,
+			&movement.Amount,
+			&description,
+			&movement.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if fromCategoryID.Valid {
+			movement.FromCategoryID = &fromCategoryID.Int64
+		}
+		if transactionID.Valid {
+			movement.TransactionID = &transactionID.Int64
+		}
+		if description.Valid {
+			movement.Description = &description.String
+		}
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+// envelopePeriods loads the boundaries, budgets, spent amounts and movements
+// of every fiscal month from the start up to the end month.
+func (s *Service) envelopePeriods(startMonth, startYear, endMonth, endYear int, incomeCategoryID int64) ([]EnvelopePeriod, error) {
+	periods := make([]EnvelopePeriod, 0)
+	for month, year := startMonth, startYear; compareMonths(month, year, endMonth, endYear) <= 0; month, year = nextMonth(month, year) {
+		start, end, err := s.intervalService.GetFiscalMonthWithIncomeCategoryID(month, year, incomeCategoryID)
+		if err != nil {
+			return nil, err
+		}
+
+		budgets, err := s.List(month, year)
+		if err != nil {
+			return nil, err
+		}
+
+		sums, err := s.transactionService.SumByCategory(*start, *end)
+		if err != nil {
+			return nil, err
+		}
+		spent := make(map[int64]float64)
+		for _, sum := range sums {
+			if sum.CategoryID != nil {
+				spent[*sum.CategoryID] = -sum.Sum
+			}
+		}
+
+		movements, err := s.listMovements(month, year)
+		if err != nil {
+			return nil, err
+		}
+
+		periods = append(periods, EnvelopePeriod{
+			Year:      year,
+			Month:     month,
+			Start:     *start,
+			End:       *end,
+			Budgets:   budgets,
+			Spent:     spent,
+			Movements: movements,
+		})
+	}
+	return periods, nil
+}
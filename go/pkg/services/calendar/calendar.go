@@ -0,0 +1,227 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// State is the code of a German federal state, holidays differ between the states.
+type State string
+
+const (
+	StateNone                  State = ""
+	StateBadenWuerttemberg     State = "BW"
+	StateBayern                State = "BY"
+	StateBerlin                State = "BE"
+	StateBrandenburg           State = "BB"
+	StateBremen                State = "HB"
+	StateHamburg               State = "HH"
+	StateHessen                State = "HE"
+	StateMecklenburgVorpommern State = "MV"
+	StateNiedersachsen         State = "NI"
+	StateNordrheinWestfalen    State = "NW"
+	StateRheinlandPfalz        State = "RP"
+	StateSaarland              State = "SL"
+	StateSachsen               State = "SN"
+	StateSachsenAnhalt         State = "ST"
+	StateSchleswigHolstein     State = "SH"
+	StateThueringen            State = "TH"
+)
+
+var states = []State{
+	StateBadenWuerttemberg, StateBayern, StateBerlin, StateBrandenburg, StateBremen, StateHamburg,
+	StateHessen, StateMecklenburgVorpommern, StateNiedersachsen, StateNordrheinWestfalen,
+	StateRheinlandPfalz, StateSaarland, StateSachsen, StateSachsenAnhalt, StateSchleswigHolstein,
+	StateThueringen,
+}
+
+func ParseState(value string) (State, error) {
+	if value == "" {
+		return StateNone, nil
+	}
+	for _, state := range states {
+		if State(value) == state {
+			return state, nil
+		}
+	}
+	return StateNone, fmt.Errorf("%w: unknown state %s", ErrInvalidSettings, value)
+}
+
+type Holiday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+	// TARGET2 is set, if the TARGET2 payment system is closed, so no transfers are settled.
+	TARGET2 bool `json:"target2"`
+}
+
+// Settings configure the state whose holidays are observed in addition to the federal ones.
+type Settings struct {
+	State State `json:"state"`
+}
+
+// Calendar is a bank business day calendar. Weekends, TARGET2 holidays and the
+// German federal holidays as well as the holidays of the state are no business days.
+type Calendar struct {
+	state State
+}
+
+func New(state State) *Calendar {
+	return &Calendar{
+		state: state,
+	}
+}
+
+// Easter returns Easter Sunday of the year using the anonymous Gregorian algorithm.
+func Easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return date(year, time.Month(month), day)
+}
+
+// TARGET2Holidays returns the days the TARGET2 payment system is closed besides weekends.
+func TARGET2Holidays(year int) []Holiday {
+	easter := Easter(year)
+	return []Holiday{
+		{Date: date(year, time.January, 1), Name: "Neujahr", TARGET2: true},
+		{Date: easter.AddDate(0, 0, -2), Name: "Karfreitag", TARGET2: true},
+		{Date: easter.AddDate(0, 0, 1), Name: "Ostermontag", TARGET2: true},
+		{Date: date(year, time.May, 1), Name: "Tag der Arbeit", TARGET2: true},
+		{Date: date(year, time.December, 25), Name: "1. Weihnachtstag", TARGET2: true},
+		{Date: date(year, time.December, 26), Name: "2. Weihnachtstag", TARGET2: true},
+	}
+}
+
+// GermanHolidays returns the federal holidays and the holidays of the state, which may be none.
+func GermanHolidays(year int, state State) []Holiday {
+	easter := Easter(year)
+	holidays := []Holiday{
+		{Date: date(year, time.January, 1), Name: "Neujahr"},
+		{Date: easter.AddDate(0, 0, -2), Name: "Karfreitag"},
+		{Date: easter.AddDate(0, 0, 1), Name: "Ostermontag"},
+		{Date: date(year, time.May, 1), Name: "Tag der Arbeit"},
+		{Date: easter.AddDate(0, 0, 39), Name: "Christi Himmelfahrt"},
+		{Date: easter.AddDate(0, 0, 50), Name: "Pfingstmontag"},
+		{Date: date(year, time.October, 3), Name: "Tag der Deutschen Einheit"},
+		{Date: date(year, time.December, 25), Name: "1. Weihnachtstag"},
+		{Date: date(year, time.December, 26), Name: "2. Weihnachtstag"},
+	}
+
+	in := func(states ...State) bool {
+		for _, s := range states {
+			if s == state {
+				return true
+			}
+		}
+		return false
+	}
+
+	if in(StateBadenWuerttemberg, StateBayern, StateSachsenAnhalt) {
+		holidays = append(holidays, Holiday{Date: date(year, time.January, 6), Name: "Heilige Drei Könige"})
+	}
+	if (state == StateBerlin && year >= 2019) || (state == StateMecklenburgVorpommern && year >= 2023) {
+		holidays = append(holidays, Holiday{Date: date(year, time.March, 8), Name: "Internationaler Frauentag"})
+	}
+	if in(StateBadenWuerttemberg, StateBayern, StateHessen, StateNordrheinWestfalen, StateRheinlandPfalz, StateSaarland) {
+		holidays = append(holidays, Holiday{Date: easter.AddDate(0, 0, 60), Name: "Fronleichnam"})
+	}
+	if state == StateSaarland {
+		holidays = append(holidays, Holiday{Date: date(year, time.August, 15), Name: "Mariä Himmelfahrt"})
+	}
+	if state == StateThueringen && year >= 2019 {
+		holidays = append(holidays, Holiday{Date: date(year, time.September, 20), Name: "Weltkindertag"})
+	}
+	// the 500th anniversary of the reformation was a federal holiday
+	if year == 2017 || in(StateBrandenburg, StateMecklenburgVorpommern, StateSachsen, StateSachsenAnhalt, StateThueringen) ||
+		(year >= 2018 && in(StateBremen, StateHamburg, StateNiedersachsen, StateSchleswigHolstein)) {
+		holidays = append(holidays, Holiday{Date: date(year, time.October, 31), Name: "Reformationstag"})
+	}
+	if in(StateBadenWuerttemberg, StateBayern, StateNordrheinWestfalen, StateRheinlandPfalz, StateSaarland) {
+		holidays = append(holidays, Holiday{Date: date(year, time.November, 1), Name: "Allerheiligen"})
+	}
+	if state == StateSachsen {
+		// the Wednesday before the 23rd of November
+		repentance := date(year, time.November, 22)
+		for repentance.Weekday() != time.Wednesday {
+			repentance = repentance.AddDate(0, 0, -1)
+		}
+		holidays = append(holidays, Holiday{Date: repentance, Name: "Buß- und Bettag"})
+	}
+
+	return holidays
+}
+
+// Holidays returns the TARGET2 and German holidays of the year ordered by date.
+func (c *Calendar) Holidays(year int) []Holiday {
+	byDate := make(map[time.Time]Holiday)
+	for _, holiday := range GermanHolidays(year, c.state) {
+		byDate[holiday.Date] = holiday
+	}
+	for _, holiday := range TARGET2Holidays(year) {
+		byDate[holiday.Date] = holiday
+	}
+
+	holidays := make([]Holiday, 0, len(byDate))
+	for _, holiday := range byDate {
+		holidays = append(holidays, holiday)
+	}
+	sort.Slice(holidays, func(i, j int) bool {
+		return holidays[i].Date.Before(holidays[j].Date)
+	})
+	return holidays
+}
+
+func (c *Calendar) IsHoliday(day time.Time) bool {
+	day = truncate(day)
+	for _, holiday := range c.Holidays(day.Year()) {
+		if holiday.Date.Equal(day) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Calendar) IsBusinessDay(day time.Time) bool {
+	if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		return false
+	}
+	return !c.IsHoliday(day)
+}
+
+// NextBusinessDay returns the day itself, if it is a business day, or the following business day.
+func (c *Calendar) NextBusinessDay(day time.Time) time.Time {
+	day = truncate(day)
+	for !c.IsBusinessDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// PreviousBusinessDay returns the day itself, if it is a business day, or the preceding business day.
+func (c *Calendar) PreviousBusinessDay(day time.Time) time.Time {
+	day = truncate(day)
+	for !c.IsBusinessDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func truncate(day time.Time) time.Time {
+	return date(day.Year(), day.Month(), day.Day())
+}
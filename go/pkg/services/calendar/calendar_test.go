@@ -0,0 +1,97 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Easter(t *testing.T) {
+	tests := []struct {
+		year int
+		want time.Time
+	}{
+		{year: 2019, want: date(2019, time.April, 21)},
+		{year: 2024, want: date(2024, time.March, 31)},
+		{year: 2025, want: date(2025, time.April, 20)},
+		{year: 2038, want: date(2038, time.April, 25)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want.Format(time.DateOnly), func(t *testing.T) {
+			assert.Equal(t, tt.want, Easter(tt.year))
+		})
+	}
+}
+
+func Test_Calendar_IsHoliday(t *testing.T) {
+	tests := []struct {
+		name  string
+		state State
+		date  time.Time
+		want  bool
+	}{
+		{name: "should observe good friday", state: StateNone, date: date(2024, time.March, 29), want: true},
+		{name: "should observe whit monday", state: StateNone, date: date(2024, time.May, 20), want: true},
+		{name: "should observe german unity day", state: StateNone, date: date(2024, time.October, 3), want: true},
+		{name: "should not observe state holidays without a state", state: StateNone, date: date(2024, time.November, 1), want: false},
+		{name: "should observe all saints in bavaria", state: StateBayern, date: date(2024, time.November, 1), want: true},
+		{name: "should observe corpus christi in hesse", state: StateHessen, date: date(2024, time.May, 30), want: true},
+		{name: "should observe reformation day in lower saxony since 2018", state: StateNiedersachsen, date: date(2018, time.October, 31), want: true},
+		{name: "should not observe reformation day in lower saxony before 2018", state: StateNiedersachsen, date: date(2016, time.October, 31), want: false},
+		{name: "should observe reformation day everywhere in 2017", state: StateBayern, date: date(2017, time.October, 31), want: true},
+		{name: "should observe women's day in berlin", state: StateBerlin, date: date(2024, time.March, 8), want: true},
+		{name: "should observe repentance day in saxony", state: StateSachsen, date: date(2024, time.November, 20), want: true},
+		{name: "should not observe repentance day in other states", state: StateBerlin, date: date(2024, time.November, 20), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, New(tt.state).IsHoliday(tt.date))
+		})
+	}
+}
+
+func Test_Calendar_Holidays(t *testing.T) {
+	holidays := New(StateNone).Holidays(2024)
+
+	assert.Len(t, holidays, 9)
+	assert.Equal(t, Holiday{Date: date(2024, time.January, 1), Name: "Neujahr", TARGET2: true}, holidays[0])
+	assert.Equal(t, Holiday{Date: date(2024, time.May, 9), Name: "Christi Himmelfahrt", TARGET2: false}, holidays[4])
+	for i := 1; i < len(holidays); i++ {
+		assert.True(t, holidays[i-1].Date.Before(holidays[i].Date))
+	}
+}
+
+func Test_Calendar_BusinessDays(t *testing.T) {
+	cal := New(StateBadenWuerttemberg)
+	tests := []struct {
+		name         string
+		date         time.Time
+		wantNext     time.Time
+		wantPrevious time.Time
+	}{
+		{name: "should keep business days", date: date(2024, time.May, 15), wantNext: date(2024, time.May, 15), wantPrevious: date(2024, time.May, 15)},
+		{name: "should skip weekends", date: date(2024, time.June, 1), wantNext: date(2024, time.June, 3), wantPrevious: date(2024, time.May, 31)},
+		{name: "should skip easter", date: date(2024, time.March, 29), wantNext: date(2024, time.April, 2), wantPrevious: date(2024, time.March, 28)},
+		{name: "should skip christmas", date: date(2024, time.December, 25), wantNext: date(2024, time.December, 27), wantPrevious: date(2024, time.December, 24)},
+		{name: "should skip epiphany", date: date(2025, time.January, 6), wantNext: date(2025, time.January, 7), wantPrevious: date(2025, time.January, 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantNext, cal.NextBusinessDay(tt.date))
+			assert.Equal(t, tt.wantPrevious, cal.PreviousBusinessDay(tt.date))
+		})
+	}
+}
+
+func Test_ParseState(t *testing.T) {
+	state, err := ParseState("NW")
+	assert.NoError(t, err)
+	assert.Equal(t, StateNordrheinWestfalen, state)
+
+	_, err = ParseState("XX")
+	assert.ErrorIs(t, err, ErrInvalidSettings)
+}
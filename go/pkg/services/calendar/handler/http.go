@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service         *calendar.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         calendar.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	calendarAPI := router.Group("/calendar")
+	calendarAPI.GET("/holidays", handler.ListHolidays)
+	calendarAPI.GET("/business-day", handler.GetBusinessDay)
+	calendarAPI.GET("/settings", handler.GetSettings)
+	calendarAPI.PUT("/settings", handler.SetSettings)
+
+	return handler
+}
+
+// ListHolidays returns the TARGET2 and German holidays of the passed year or the current year.
+func (h *Handler) ListHolidays(c *gin.Context) {
+	year := time.Now().Year()
+	if rawYear := c.Query("year"); rawYear != "" {
+		var err error
+		year, err = strconv.Atoi(rawYear)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	cal, err := h.Service.Calendar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cal.Holidays(year))
+}
+
+// GetBusinessDay returns whether the passed date is a business day and the surrounding business days.
+func (h *Handler) GetBusinessDay(c *gin.Context) {
+	date, err := time.Parse(time.DateOnly, c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cal, err := h.Service.Calendar()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":                date,
+		"businessDay":         cal.IsBusinessDay(date),
+		"previousBusinessDay": cal.PreviousBusinessDay(date),
+		"nextBusinessDay":     cal.NextBusinessDay(date),
+	})
+}
+
+func (h *Handler) GetSettings(c *gin.Context) {
+	settings, err := h.Service.Settings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *Handler) SetSettings(c *gin.Context) {
+	var settings calendar.Settings
+	err := c.BindJSON(&settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Service.SetSettings(settings)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// fixed fiscal month starts may be shifted by the holidays of the new state
+	err = h.IntervalService.InvalidateAllFiscalMonths()
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, calendar.ErrInvalidSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,78 @@
+package calendar
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidSettings = errors.New("invalid calendar settings")
+
+// settingsKey is the key the calendar settings of the household are stored with.
+const settingsKey = "calendar"
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// Settings returns the calendar settings of the household. Without settings only federal holidays are observed.
+func (s *Service) Settings() (*Settings, error) {
+	var settings Settings
+
+	var value []byte
+	err := s.db.QueryRow(`
+		SELECT value
+		FROM settings
+		WHERE key = $1;
+	`, settingsKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(value, &settings)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (s *Service) SetSettings(settings Settings) (*Settings, error) {
+	_, err := ParseState(string(settings.State))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO settings (key, value)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value;
+	`, settingsKey, value)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Calendar returns the business day calendar of the household.
+func (s *Service) Calendar() (*Calendar, error) {
+	settings, err := s.Settings()
+	if err != nil {
+		return nil, err
+	}
+	return New(settings.State), nil
+}
@@ -3,6 +3,8 @@ package category
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 )
 
 type Category struct {
@@ -10,15 +12,33 @@ type Category struct {
 	Name        string         `json:"name"`
 	Description *string        `json:"description"`
 	Color       *string        `json:"color"`
+	Archived    bool           `json:"archived"`
+	Transfer    bool           `json:"transfer"`
 	Rules       []CategoryRule `json:"rules,omitempty"`
 }
 
+type CategoryPatchRequest struct {
+	Archived *bool `json:"archived"`
+	Transfer *bool `json:"transfer"`
+}
+
+type CategoryMergeRequest struct {
+	TargetID int64 `json:"targetID"`
+}
+
 type CategoryRule struct {
 	ID           int64        `json:"id"`
 	CategoryID   int64        `json:"categoryID"`
 	MappingField MappingField `json:"mappingField"`
 	Regex        string       `json:"regex"`
 	Description  *string      `json:"description"`
+
+	// HitCount is the number of transactions the rule assigned its category to.
+	HitCount int64 `json:"hitCount"`
+	// ShadowedCount is the number of transactions the rule matched,
+	// but an earlier evaluated rule assigned another category instead.
+	ShadowedCount int64      `json:"shadowedCount"`
+	LastHitDate   *time.Time `json:"lastHitDate"`
 }
 
 type MappingField string
@@ -27,8 +47,29 @@ const (
 	MappingFieldRecipient   MappingField = "recipient"
 	MappingFieldBookingText MappingField = "booking_text"
 	MappingFieldPurpose     MappingField = "purpose"
+	// MappingFieldPayee matches the name of the normalized payee instead of the raw recipient.
+	MappingFieldPayee MappingField = "payee"
+	// MappingFieldIBAN and MappingFieldCreditorID match the SEPA fields extracted from
+	// the purpose exactly, ignoring case and whitespace, instead of using the regex.
+	MappingFieldIBAN       MappingField = "iban"
+	MappingFieldCreditorID MappingField = "creditor_id"
 )
 
+func (f MappingField) Valid() bool {
+	switch f {
+	case MappingFieldRecipient, MappingFieldBookingText, MappingFieldPurpose, MappingFieldPayee,
+		MappingFieldIBAN, MappingFieldCreditorID:
+		return true
+	}
+	return false
+}
+
+// Exact returns whether rules of the mapping field compare
+// their value exactly instead of using it as regex.
+func (f MappingField) Exact() bool {
+	return f == MappingFieldIBAN || f == MappingFieldCreditorID
+}
+
 func (r *CategoryRule) Match(value string) (bool, error) {
 	regex, err := regexp.Compile(fmt.Sprintf("(?i)%s", r.Regex))
 	if err != nil {
@@ -36,3 +77,12 @@ func (r *CategoryRule) Match(value string) (bool, error) {
 	}
 	return regex.MatchString(value), nil
 }
+
+// MatchExact compares the passed value with the rule value, ignoring case and whitespace.
+func (r *CategoryRule) MatchExact(value string) bool {
+	return normalizeExactValue(r.Regex) == normalizeExactValue(value)
+}
+
+func normalizeExactValue(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
@@ -2,12 +2,19 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"docqube.de/bookkeeper/pkg/services/audit"
 	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/lock"
 	"github.com/gin-gonic/gin"
 )
 
+const defaultDeadRuleMonths = 6
+
 type Handler struct {
 	service *category.Service
 }
@@ -19,12 +26,30 @@ func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
 
 	categoriesAPI := router.Group("/categories")
 	categoriesAPI.GET("", handler.List)
+	categoriesAPI.GET("/export", handler.Export)
+	categoriesAPI.POST("/import", handler.Import)
+	categoriesAPI.GET("/rules/dead", handler.ListDeadRules)
+
+	categoryAPI := router.Group("/category")
+	categoryAPI.GET("/:id", handler.Get)
+	categoryAPI.PATCH("/:id", handler.Patch)
+	categoryAPI.POST("/:id/merge", handler.Merge)
 
 	return handler
 }
 
 func (h *Handler) List(c *gin.Context) {
-	categories, err := h.service.List()
+	includeArchived := false
+	if rawArchived := c.Query("archived"); rawArchived != "" {
+		var err error
+		includeArchived, err = strconv.ParseBool(rawArchived)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	categories, err := h.service.List(includeArchived)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -32,3 +57,194 @@ func (h *Handler) List(c *gin.Context) {
 
 	c.JSON(http.StatusOK, categories)
 }
+
+func (h *Handler) Export(c *gin.Context) {
+	format, err := category.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	set, err := h.service.Export()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch format {
+	case category.FormatYAML:
+		c.Header("Content-Type", "application/yaml; charset=utf-8")
+	default:
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Header("Content-Disposition", "attachment; filename=categories."+string(format))
+	c.Status(http.StatusOK)
+
+	err = category.EncodeCategorySet(c.Writer, set, format)
+	if err != nil {
+		_ = c.Error(err)
+	}
+}
+
+func (h *Handler) Import(c *gin.Context) {
+	format, err := category.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mode, err := category.ParseImportMode(c.Query("mode"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dryRun := false
+	if rawDryRun := c.Query("dryRun"); rawDryRun != "" {
+		dryRun, err = strconv.ParseBool(rawDryRun)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	set, err := category.DecodeCategorySet(c.Request.Body, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var preview *category.ImportPreview
+	if dryRun {
+		preview, err = h.service.PreviewImport(set, mode)
+	} else {
+		preview, err = h.service.Import(audit.ActorFromRequest(c.Request), set, mode)
+	}
+	if err != nil {
+		if errors.Is(err, category.ErrInvalidCategorySet) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h *Handler) ListDeadRules(c *gin.Context) {
+	months := defaultDeadRuleMonths
+	if rawMonths := c.Query("months"); rawMonths != "" {
+		var err error
+		months, err = strconv.Atoi(rawMonths)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	deadRules, err := h.service.ListDeadRules(time.Now().UTC().AddDate(0, -months, 0))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deadRules)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	category, err := h.service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *Handler) Patch(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patchRequest category.CategoryPatchRequest
+	err = c.BindJSON(&patchRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor := audit.ActorFromRequest(c.Request)
+	if patchRequest.Archived != nil {
+		err = h.service.Archive(actor, id, *patchRequest.Archived)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+	if patchRequest.Transfer != nil {
+		err = h.service.SetTransfer(actor, id, *patchRequest.Transfer)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
+	category, err := h.service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func (h *Handler) Merge(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var mergeRequest category.CategoryMergeRequest
+	err = c.BindJSON(&mergeRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.service.Merge(audit.ActorFromRequest(c.Request), id, mergeRequest.TargetID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	category, err := h.service.Get(mergeRequest.TargetID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, category)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, category.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, category.ErrMergeIntoItself):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, lock.ErrPeriodLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
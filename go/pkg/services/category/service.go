@@ -2,8 +2,17 @@ package category
 
 import (
 	"database/sql"
+	"errors"
 	"sync"
 	"time"
+
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"docqube.de/bookkeeper/pkg/services/lock"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrMergeIntoItself  = errors.New("category can not be merged into itself")
 )
 
 var (
@@ -13,20 +22,35 @@ var (
 )
 
 type Service struct {
-	db *sql.DB
+	db          *sql.DB
+	lockService *lock.Service
 }
 
 func NewService(db *sql.DB) *Service {
 	return &Service{
-		db: db,
+		db:          db,
+		lockService: lock.NewService(db),
 	}
 }
 
-func (s *Service) List() ([]Category, error) {
-	rows, err := s.db.Query(`
-		SELECT id, name, description, color
-		FROM categories;
-	`)
+// queryer is implemented by both database connections and transactions.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// List returns all categories including their rules. Archived categories are
+// only included if requested, as they must not match new transactions anymore.
+func (s *Service) List(includeArchived bool) ([]Category, error) {
+	return list(s.db, includeArchived)
+}
+
+func list(db queryer, includeArchived bool) ([]Category, error) {
+	rows, err := db.Query(`
+		SELECT id, name, description, color, archived, transfer
+		FROM categories
+		WHERE archived = false OR $1
+		ORDER BY id;
+	`, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +64,7 @@ func (s *Service) List() ([]Category, error) {
 			rawColor       sql.NullString
 		)
 
-		err = rows.Scan(&category.ID, &category.Name, &rawDescription, &rawColor)
+		err = rows.Scan(&category.ID, &category.Name, &rawDescription, &rawColor, &category.Archived, &category.Transfer)
 		if err != nil {
 			return nil, err
 		}
@@ -52,23 +76,339 @@ func (s *Service) List() ([]Category, error) {
 			category.Color = &rawColor.String
 		}
 
-		rules, err := s.GetRules(category.ID)
+		categories = append(categories, category)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	// the rules are queried after closing the rows, as a transaction can't run
+	// another query while the rows of the previous one are read
+	rows.Close()
+
+	for i := range categories {
+		categories[i].Rules, err = getRules(db, categories[i].ID)
 		if err != nil {
 			return nil, err
 		}
-		category.Rules = rules
-
-		categories = append(categories, category)
 	}
 
 	return categories, nil
 }
 
+func (s *Service) Get(id int64) (*Category, error) {
+	var (
+		category       Category
+		rawDescription sql.NullString
+		rawColor       sql.NullString
+	)
+	err := s.db.QueryRow(`
+		SELECT id, name, description, color, archived, transfer
+		FROM categories
+		WHERE id = $1;
+	`, id).Scan(&category.ID, &category.Name, &rawDescription, &rawColor, &category.Archived, &category.Transfer)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+
+	if rawDescription.Valid {
+		category.Description = &rawDescription.String
+	}
+	if rawColor.Valid {
+		category.Color = &rawColor.String
+	}
+
+	rules, err := s.GetRules(category.ID)
+	if err != nil {
+		return nil, err
+	}
+	category.Rules = rules
+
+	return &category, nil
+}
+
+// SetTransfer marks the category with the passed id as transfer between own accounts or
+// removes the mark. Transactions of transfer categories are neither income nor expenses.
+func (s *Service) SetTransfer(actor audit.Actor, id int64, transfer bool) error {
+	result, err := audit.Exec(s.db, actor, `
+		UPDATE categories
+		SET transfer = $1
+		WHERE id = $2;
+	`, transfer, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// Archive archives or restores the category with the passed id. Archived categories
+// keep their transactions, but are not used for matching or picking anymore.
+func (s *Service) Archive(actor audit.Actor, id int64, archived bool) error {
+	result, err := audit.Exec(s.db, actor, `
+		UPDATE categories
+		SET archived = $1
+		WHERE id = $2;
+	`, archived, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// Merge moves all transactions, rules, budgets and envelopes of the source category to the
+// target category and deletes the source category in a single database transaction.
+// Categories with transactions booked within a locked period can't be merged.
+func (s *Service) Merge(actor audit.Actor, sourceID, targetID int64) error {
+	if sourceID == targetID {
+		return ErrMergeIntoItself
+	}
+
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// both categories and the transactions of the source category are locked, so they
+	// can't change between the lock check and the merge
+	rows, err := tx.Query(`
+		SELECT id
+		FROM categories
+		WHERE id IN ($1, $2)
+		ORDER BY id
+		FOR UPDATE;
+	`, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+	count := 0
+	for rows.Next() {
+		count++
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	if count != 2 {
+		return ErrCategoryNotFound
+	}
+
+	err = lockTransactions(tx, sourceID)
+	if err != nil {
+		return err
+	}
+	err = s.lockService.WithTx(tx).CheckCategory(sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET category_id = $1
+		WHERE category_id = $2;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE category_rules
+		SET category_id = $1
+		WHERE category_id = $2;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	err = mergeBudgets(tx, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	err = mergeEnvelopes(tx, sourceID, targetID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM categories
+		WHERE id = $1;
+	`, sourceID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockTransactions locks the transactions of the category until the database transaction ends.
+func lockTransactions(tx *sql.Tx, categoryID int64) error {
+	_, err := tx.Exec(`
+		SELECT id
+		FROM transactions
+		WHERE category_id = $1
+		ORDER BY id
+		FOR UPDATE;
+	`, categoryID)
+	return err
+}
+
+// mergeBudgets moves the budget template and the monthly budgets of the source category to the
+// target category. Amounts of months budgeted for both categories are added up.
+func mergeBudgets(tx *sql.Tx, sourceID, targetID int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO budget_templates (category_id, amount)
+		SELECT $1, amount
+		FROM budget_templates
+		WHERE category_id = $2
+		ON CONFLICT (category_id) DO UPDATE
+		SET amount = budget_templates.amount + EXCLUDED.amount;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO budgets (category_id, year, month, amount)
+		SELECT $1, year, month, amount
+		FROM budgets
+		WHERE category_id = $2
+		ON CONFLICT (category_id, year, month) DO UPDATE
+		SET amount = budgets.amount + EXCLUDED.amount;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	return deleteBudgets(tx, sourceID)
+}
+
+// deleteBudgets deletes the budget template and the monthly budgets of the category.
+func deleteBudgets(tx *sql.Tx, categoryID int64) error {
+	_, err := tx.Exec(`
+		DELETE FROM budget_templates
+		WHERE category_id = $1;
+	`, categoryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM budgets
+		WHERE category_id = $1;
+	`, categoryID)
+	return err
+}
+
+// mergeEnvelopes moves the envelope of the source category and its movements to the target
+// category. If both categories have an envelope, the earlier start is kept. Movements between
+// both envelopes are deleted, as they don't move anything anymore.
+func mergeEnvelopes(tx *sql.Tx, sourceID, targetID int64) error {
+	_, err := tx.Exec(`
+		INSERT INTO envelopes (category_id, start_year, start_month)
+		SELECT $1, start_year, start_month
+		FROM envelopes
+		WHERE category_id = $2
+		ON CONFLICT (category_id) DO UPDATE
+		SET
+			start_year = CASE
+				WHEN (EXCLUDED.start_year, EXCLUDED.start_month) < (envelopes.start_year, envelopes.start_month)
+				THEN EXCLUDED.start_year
+				ELSE envelopes.start_year
+			END,
+			start_month = CASE
+				WHEN (EXCLUDED.start_year, EXCLUDED.start_month) < (envelopes.start_year, envelopes.start_month)
+				THEN EXCLUDED.start_month
+				ELSE envelopes.start_month
+			END;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM envelope_movements
+		WHERE
+			(from_category_id = $1 AND to_category_id = $2)
+		OR
+			(from_category_id = $2 AND to_category_id = $1);
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE envelope_movements
+		SET from_category_id = $1
+		WHERE from_category_id = $2;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE envelope_movements
+		SET to_category_id = $1
+		WHERE to_category_id = $2;
+	`, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM envelopes
+		WHERE category_id = $1;
+	`, sourceID)
+	return err
+}
+
+// deleteEnvelope deletes the envelope of the category and all movements from and into it.
+func deleteEnvelope(tx *sql.Tx, categoryID int64) error {
+	_, err := tx.Exec(`
+		DELETE FROM envelope_movements
+		WHERE from_category_id = $1 OR to_category_id = $1;
+	`, categoryID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM envelopes
+		WHERE category_id = $1;
+	`, categoryID)
+	return err
+}
+
 func (s *Service) GetRules(categoryID int64) ([]CategoryRule, error) {
-	rows, err := s.db.Query(`
-		SELECT id, category_id, regex, mapping_field, description
+	return getRules(s.db, categoryID)
+}
+
+func getRules(db queryer, categoryID int64) ([]CategoryRule, error) {
+	rows, err := db.Query(`
+		SELECT id, category_id, regex, mapping_field, description, hit_count, shadowed_count, last_hit_date
 		FROM category_rules
-		WHERE category_id = $1;
+		WHERE category_id = $1
+		ORDER BY id;
 	`, categoryID)
 	if err != nil {
 		return nil, err
@@ -80,9 +420,19 @@ func (s *Service) GetRules(categoryID int64) ([]CategoryRule, error) {
 		var (
 			rule           CategoryRule
 			rawDescription sql.NullString
+			rawLastHitDate sql.NullTime
 		)
 
-		err = rows.Scan(&rule.ID, &rule.CategoryID, &rule.Regex, &rule.MappingField, &rawDescription)
+		err = rows.Scan(
+			&rule.ID,
+			&rule.CategoryID,
+			&rule.Regex,
+			&rule.MappingField,
+			&rawDescription,
+			&rule.HitCount,
+			&rule.ShadowedCount,
+			&rawLastHitDate,
+		)
 		if err != nil {
 			return nil, err
 		}
@@ -90,9 +440,226 @@ func (s *Service) GetRules(categoryID int64) ([]CategoryRule, error) {
 		if rawDescription.Valid {
 			rule.Description = &rawDescription.String
 		}
+		if rawLastHitDate.Valid {
+			rule.LastHitDate = &rawLastHitDate.Time
+		}
 
 		rules = append(rules, rule)
 	}
 
 	return rules, nil
 }
+
+// RecordRuleHits adds the collected rule statistics to the persisted ones.
+func (s *Service) RecordRuleHits(hits *RuleHits) error {
+	if hits.Empty() {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for ruleID, count := range hits.hits {
+		_, err = tx.Exec(`
+			UPDATE category_rules
+			SET
+				hit_count = hit_count + $1,
+				last_hit_date = GREATEST(last_hit_date, $2)
+			WHERE id = $3;
+		`, count, hits.lastHit[ruleID], ruleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for ruleID, count := range hits.shadowed {
+		_, err = tx.Exec(`
+			UPDATE category_rules
+			SET shadowed_count = shadowed_count + $1
+			WHERE id = $2;
+		`, count, ruleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListDeadRules returns all rules of active categories, that did not assign their
+// category to a transaction booked since the passed date or are always shadowed.
+func (s *Service) ListDeadRules(since time.Time) ([]DeadRule, error) {
+	categories, err := s.List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	deadRules := make([]DeadRule, 0)
+	for _, c := range categories {
+		for _, rule := range c.Rules {
+			reason := rule.DeadReason(since)
+			if reason == "" {
+				continue
+			}
+
+			category := c
+			category.Rules = nil
+			deadRules = append(deadRules, DeadRule{
+				Rule:     rule,
+				Category: category,
+				Reason:   reason,
+			})
+		}
+	}
+	return deadRules, nil
+}
+
+// Export returns all categories including their rules as a category set.
+func (s *Service) Export() (*CategorySet, error) {
+	categories, err := s.List(true)
+	if err != nil {
+		return nil, err
+	}
+	return NewCategorySet(categories), nil
+}
+
+// PreviewImport returns the changes an import of the passed category
+// set with the passed import mode would apply, without applying them.
+func (s *Service) PreviewImport(set *CategorySet, mode ImportMode) (*ImportPreview, error) {
+	categories, err := s.List(true)
+	if err != nil {
+		return nil, err
+	}
+	return DiffCategorySet(categories, set, mode), nil
+}
+
+// Import applies the passed category set with the passed import mode in a single
+// database transaction. The changes are computed within it, while the categories and
+// their rules are locked. Budgets and envelopes of deleted categories are deleted and
+// their transactions become unclassified, so categories with transactions booked within
+// a locked period can't be deleted.
+func (s *Service) Import(actor audit.Actor, set *CategorySet, mode ImportMode) (*ImportPreview, error) {
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the rows are locked before they are read, so neither the categories nor their rules
+	// can change until the import is applied
+	_, err = tx.Exec(`
+		SELECT id
+		FROM categories
+		ORDER BY id
+		FOR UPDATE;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`
+		SELECT id
+		FROM category_rules
+		ORDER BY id
+		FOR UPDATE;
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := list(tx, true)
+	if err != nil {
+		return nil, err
+	}
+	preview := DiffCategorySet(categories, set, mode)
+
+	lockService := s.lockService.WithTx(tx)
+	for _, change := range preview.Categories {
+		if change.Action != ChangeActionDelete {
+			continue
+		}
+		err = lockTransactions(tx, change.ID)
+		if err != nil {
+			return nil, err
+		}
+		err = lockService.CheckCategory(change.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, change := range preview.Categories {
+		categoryID := change.ID
+
+		switch change.Action {
+		case ChangeActionCreate:
+			err = tx.QueryRow(`
+				INSERT INTO categories (name, description, color, archived, transfer)
+				VALUES ($1, $2, $3, $4, $5)
+				RETURNING id;
+			`, change.Name, change.Description, change.Color, change.Archived, change.Transfer).Scan(&categoryID)
+			preview.Categories[i].ID = categoryID
+		case ChangeActionUpdate:
+			_, err = tx.Exec(`
+				UPDATE categories
+				SET description = $1, color = $2, archived = $3, transfer = $4
+				WHERE id = $5;
+			`, change.Description, change.Color, change.Archived, change.Transfer, categoryID)
+		case ChangeActionDelete:
+			_, err = tx.Exec(`
+				UPDATE transactions
+				SET category_id = NULL
+				WHERE category_id = $1;
+			`, categoryID)
+			if err == nil {
+				err = deleteBudgets(tx, categoryID)
+			}
+			if err == nil {
+				err = deleteEnvelope(tx, categoryID)
+			}
+			if err == nil {
+				_, err = tx.Exec(`
+					DELETE FROM categories
+					WHERE id = $1;
+				`, categoryID)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ruleChange := range change.Rules {
+			switch ruleChange.Action {
+			case ChangeActionCreate:
+				_, err = tx.Exec(`
+					INSERT INTO category_rules (category_id, regex, mapping_field, description)
+					VALUES ($1, $2, $3, $4);
+				`, categoryID, ruleChange.Rule.Regex, ruleChange.Rule.MappingField, ruleChange.Rule.Description)
+			case ChangeActionUpdate:
+				_, err = tx.Exec(`
+					UPDATE category_rules
+					SET description = $1
+					WHERE id = $2;
+				`, ruleChange.Rule.Description, ruleChange.ID)
+			case ChangeActionDelete:
+				_, err = tx.Exec(`
+					DELETE FROM category_rules
+					WHERE id = $1;
+				`, ruleChange.ID)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	preview.Applied = true
+	return preview, nil
+}
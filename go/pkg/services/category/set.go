@@ -0,0 +1,382 @@
+package category
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CategorySetVersion is the version of the exported category set document.
+// It has to be increased on every incompatible change of the document layout.
+const CategorySetVersion = 1
+
+var (
+	ErrInvalidCategorySet = errors.New("invalid category set")
+	ErrUnknownFormat      = errors.New("unknown format")
+	ErrUnknownImportMode  = errors.New("unknown import mode")
+)
+
+type CategorySet struct {
+	Version    int                  `json:"version" yaml:"version"`
+	Categories []CategoryDefinition `json:"categories" yaml:"categories"`
+}
+
+type CategoryDefinition struct {
+	Name        string           `json:"name" yaml:"name"`
+	Description *string          `json:"description,omitempty" yaml:"description,omitempty"`
+	Color       *string          `json:"color,omitempty" yaml:"color,omitempty"`
+	Archived    *bool            `json:"archived,omitempty" yaml:"archived,omitempty"`
+	Transfer    *bool            `json:"transfer,omitempty" yaml:"transfer,omitempty"`
+	Rules       []RuleDefinition `json:"rules,omitempty" yaml:"rules,omitempty"`
+}
+
+type RuleDefinition struct {
+	MappingField MappingField `json:"mappingField" yaml:"mappingField"`
+	Regex        string       `json:"regex" yaml:"regex"`
+	Description  *string      `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+)
+
+type ImportMode string
+
+const (
+	// ImportModeMerge creates missing categories and rules
+	// and keeps everything that is not part of the import.
+	ImportModeMerge ImportMode = "merge"
+	// ImportModeReplace makes the existing categories and rules
+	// match the import exactly and deletes everything else.
+	ImportModeReplace ImportMode = "replace"
+)
+
+type ChangeAction string
+
+const (
+	ChangeActionCreate ChangeAction = "create"
+	ChangeActionUpdate ChangeAction = "update"
+	ChangeActionDelete ChangeAction = "delete"
+)
+
+type ImportPreview struct {
+	Mode       ImportMode       `json:"mode"`
+	Applied    bool             `json:"applied"`
+	Categories []CategoryChange `json:"categories"`
+}
+
+type CategoryChange struct {
+	Action      ChangeAction `json:"action,omitempty"`
+	ID          int64        `json:"id,omitempty"`
+	Name        string       `json:"name"`
+	Description *string      `json:"description,omitempty"`
+	Color       *string      `json:"color,omitempty"`
+	Archived    bool         `json:"archived"`
+	Transfer    bool         `json:"transfer"`
+	Rules       []RuleChange `json:"rules,omitempty"`
+}
+
+type RuleChange struct {
+	Action ChangeAction   `json:"action"`
+	ID     int64          `json:"id,omitempty"`
+	Rule   RuleDefinition `json:"rule"`
+}
+
+// NewCategorySet converts the passed categories including their rules into a category set.
+func NewCategorySet(categories []Category) *CategorySet {
+	set := &CategorySet{
+		Version:    CategorySetVersion,
+		Categories: make([]CategoryDefinition, 0, len(categories)),
+	}
+	for _, c := range categories {
+		definition := CategoryDefinition{
+			Name:        c.Name,
+			Description: c.Description,
+			Color:       c.Color,
+		}
+		if c.Archived {
+			definition.Archived = &c.Archived
+		}
+		if c.Transfer {
+			definition.Transfer = &c.Transfer
+		}
+		for _, rule := range c.Rules {
+			definition.Rules = append(definition.Rules, RuleDefinition{
+				MappingField: rule.MappingField,
+				Regex:        rule.Regex,
+				Description:  rule.Description,
+			})
+		}
+		set.Categories = append(set.Categories, definition)
+	}
+	return set
+}
+
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatYAML, "yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, value)
+}
+
+func ParseImportMode(value string) (ImportMode, error) {
+	switch ImportMode(value) {
+	case "", ImportModeMerge:
+		return ImportModeMerge, nil
+	case ImportModeReplace:
+		return ImportModeReplace, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownImportMode, value)
+}
+
+func EncodeCategorySet(writer io.Writer, set *CategorySet, format Format) error {
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(set)
+	case FormatYAML:
+		encoder := yaml.NewEncoder(writer)
+		encoder.SetIndent(2)
+		err := encoder.Encode(set)
+		if err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+}
+
+func DecodeCategorySet(reader io.Reader, format Format) (*CategorySet, error) {
+	var (
+		set CategorySet
+		err error
+	)
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(reader)
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&set)
+	case FormatYAML:
+		decoder := yaml.NewDecoder(reader)
+		decoder.KnownFields(true)
+		err = decoder.Decode(&set)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCategorySet, err)
+	}
+
+	err = set.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+func (s *CategorySet) Validate() error {
+	if s.Version != CategorySetVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidCategorySet, s.Version)
+	}
+
+	names := make(map[string]struct{}, len(s.Categories))
+	for _, c := range s.Categories {
+		if c.Name == "" {
+			return fmt.Errorf("%w: category name missing", ErrInvalidCategorySet)
+		}
+		if _, ok := names[c.Name]; ok {
+			return fmt.Errorf("%w: duplicate category %q", ErrInvalidCategorySet, c.Name)
+		}
+		names[c.Name] = struct{}{}
+
+		for _, rule := range c.Rules {
+			if !rule.MappingField.Valid() {
+				return fmt.Errorf("%w: category %q: unknown mapping field %q", ErrInvalidCategorySet, c.Name, rule.MappingField)
+			}
+			if rule.MappingField.Exact() {
+				continue
+			}
+			_, err := regexp.Compile(rule.Regex)
+			if err != nil {
+				return fmt.Errorf("%w: category %q: %s", ErrInvalidCategorySet, c.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// DiffCategorySet returns the changes needed to apply the passed category set
+// with the passed import mode to the existing categories. Categories are matched
+// by their name and rules by their mapping field and regex.
+func DiffCategorySet(existing []Category, set *CategorySet, mode ImportMode) *ImportPreview {
+	preview := &ImportPreview{
+		Mode:       mode,
+		Categories: make([]CategoryChange, 0),
+	}
+
+	existingByName := make(map[string]Category, len(existing))
+	for _, c := range existing {
+		existingByName[c.Name] = c
+	}
+
+	imported := make(map[string]struct{}, len(set.Categories))
+	for _, definition := range set.Categories {
+		imported[definition.Name] = struct{}{}
+
+		current, ok := existingByName[definition.Name]
+		if !ok {
+			change := CategoryChange{
+				Action:      ChangeActionCreate,
+				Name:        definition.Name,
+				Description: definition.Description,
+				Color:       definition.Color,
+				Archived:    definition.Archived != nil && *definition.Archived,
+				Transfer:    definition.Transfer != nil && *definition.Transfer,
+			}
+			for _, rule := range definition.Rules {
+				change.Rules = append(change.Rules, RuleChange{Action: ChangeActionCreate, Rule: rule})
+			}
+			preview.Categories = append(preview.Categories, change)
+			continue
+		}
+
+		// when merging, omitted optional values keep the existing ones
+		description, color := definition.Description, definition.Color
+		archived := definition.Archived != nil && *definition.Archived
+		transfer := definition.Transfer != nil && *definition.Transfer
+		if mode == ImportModeMerge {
+			description = coalesceStringPointers(description, current.Description)
+			color = coalesceStringPointers(color, current.Color)
+			if definition.Archived == nil {
+				archived = current.Archived
+			}
+			if definition.Transfer == nil {
+				transfer = current.Transfer
+			}
+		}
+
+		change := CategoryChange{
+			ID:          current.ID,
+			Name:        current.Name,
+			Description: description,
+			Color:       color,
+			Archived:    archived,
+			Transfer:    transfer,
+			Rules:       diffRules(current.Rules, definition.Rules, mode),
+		}
+		if !equalStringPointers(current.Description, description) ||
+			!equalStringPointers(current.Color, color) ||
+			current.Archived != archived ||
+			current.Transfer != transfer {
+			change.Action = ChangeActionUpdate
+		}
+		if change.Action != "" || len(change.Rules) > 0 {
+			preview.Categories = append(preview.Categories, change)
+		}
+	}
+
+	if mode == ImportModeReplace {
+		for _, c := range existing {
+			if _, ok := imported[c.Name]; ok {
+				continue
+			}
+			change := CategoryChange{
+				Action:      ChangeActionDelete,
+				ID:          c.ID,
+				Name:        c.Name,
+				Description: c.Description,
+				Color:       c.Color,
+				Archived:    c.Archived,
+				Transfer:    c.Transfer,
+			}
+			for _, rule := range c.Rules {
+				change.Rules = append(change.Rules, RuleChange{
+					Action: ChangeActionDelete,
+					ID:     rule.ID,
+					Rule:   newRuleDefinition(rule),
+				})
+			}
+			preview.Categories = append(preview.Categories, change)
+		}
+	}
+
+	return preview
+}
+
+func diffRules(existing []CategoryRule, definitions []RuleDefinition, mode ImportMode) []RuleChange {
+	changes := make([]RuleChange, 0)
+
+	existingByKey := make(map[string]CategoryRule, len(existing))
+	for _, rule := range existing {
+		existingByKey[ruleKey(rule.MappingField, rule.Regex)] = rule
+	}
+
+	imported := make(map[string]struct{}, len(definitions))
+	for _, definition := range definitions {
+		key := ruleKey(definition.MappingField, definition.Regex)
+		if _, ok := imported[key]; ok {
+			continue
+		}
+		imported[key] = struct{}{}
+
+		current, ok := existingByKey[key]
+		if !ok {
+			changes = append(changes, RuleChange{Action: ChangeActionCreate, Rule: definition})
+			continue
+		}
+		if mode == ImportModeMerge {
+			definition.Description = coalesceStringPointers(definition.Description, current.Description)
+		}
+		if !equalStringPointers(current.Description, definition.Description) {
+			changes = append(changes, RuleChange{Action: ChangeActionUpdate, ID: current.ID, Rule: definition})
+		}
+	}
+
+	if mode == ImportModeReplace {
+		for _, rule := range existing {
+			if _, ok := imported[ruleKey(rule.MappingField, rule.Regex)]; ok {
+				continue
+			}
+			changes = append(changes, RuleChange{Action: ChangeActionDelete, ID: rule.ID, Rule: newRuleDefinition(rule)})
+		}
+	}
+
+	return changes
+}
+
+func newRuleDefinition(rule CategoryRule) RuleDefinition {
+	return RuleDefinition{
+		MappingField: rule.MappingField,
+		Regex:        rule.Regex,
+		Description:  rule.Description,
+	}
+}
+
+func ruleKey(mappingField MappingField, regex string) string {
+	return fmt.Sprintf("%s\x00%s", mappingField, regex)
+}
+
+func equalStringPointers(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func coalesceStringPointers(a, b *string) *string {
+	if a != nil {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,226 @@
+package category
+
+import (
+	"strings"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DiffCategorySet(t *testing.T) {
+	existing := []Category{
+		{
+			ID:    1,
+			Name:  "Groceries",
+			Color: utils.NewString("#00ff00"),
+			Rules: []CategoryRule{
+				{ID: 11, CategoryID: 1, MappingField: MappingFieldRecipient, Regex: "lidl"},
+				{ID: 12, CategoryID: 1, MappingField: MappingFieldRecipient, Regex: "aldi"},
+			},
+		},
+		{
+			ID:   2,
+			Name: "Income",
+			Rules: []CategoryRule{
+				{ID: 21, CategoryID: 2, MappingField: MappingFieldBookingText, Regex: "gehalt"},
+			},
+		},
+	}
+	set := &CategorySet{
+		Version: CategorySetVersion,
+		Categories: []CategoryDefinition{
+			{
+				Name: "Groceries",
+				Rules: []RuleDefinition{
+					{MappingField: MappingFieldRecipient, Regex: "lidl"},
+					{MappingField: MappingFieldRecipient, Regex: "kaufland"},
+				},
+			},
+			{
+				Name:  "Mobility",
+				Color: utils.NewString("#0000ff"),
+				Rules: []RuleDefinition{
+					{MappingField: MappingFieldPurpose, Regex: "leasing"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		mode ImportMode
+		want *ImportPreview
+	}{
+		{
+			name: "should only add when merging",
+			mode: ImportModeMerge,
+			want: &ImportPreview{
+				Mode: ImportModeMerge,
+				Categories: []CategoryChange{
+					{
+						ID:    1,
+						Name:  "Groceries",
+						Color: utils.NewString("#00ff00"),
+						Rules: []RuleChange{
+							{Action: ChangeActionCreate, Rule: RuleDefinition{MappingField: MappingFieldRecipient, Regex: "kaufland"}},
+						},
+					},
+					{
+						Action: ChangeActionCreate,
+						Name:   "Mobility",
+						Color:  utils.NewString("#0000ff"),
+						Rules: []RuleChange{
+							{Action: ChangeActionCreate, Rule: RuleDefinition{MappingField: MappingFieldPurpose, Regex: "leasing"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "should delete everything missing when replacing",
+			mode: ImportModeReplace,
+			want: &ImportPreview{
+				Mode: ImportModeReplace,
+				Categories: []CategoryChange{
+					{
+						Action: ChangeActionUpdate,
+						ID:     1,
+						Name:   "Groceries",
+						Rules: []RuleChange{
+							{Action: ChangeActionCreate, Rule: RuleDefinition{MappingField: MappingFieldRecipient, Regex: "kaufland"}},
+							{Action: ChangeActionDelete, ID: 12, Rule: RuleDefinition{MappingField: MappingFieldRecipient, Regex: "aldi"}},
+						},
+					},
+					{
+						Action: ChangeActionCreate,
+						Name:   "Mobility",
+						Color:  utils.NewString("#0000ff"),
+						Rules: []RuleChange{
+							{Action: ChangeActionCreate, Rule: RuleDefinition{MappingField: MappingFieldPurpose, Regex: "leasing"}},
+						},
+					},
+					{
+						Action: ChangeActionDelete,
+						ID:     2,
+						Name:   "Income",
+						Rules: []RuleChange{
+							{Action: ChangeActionDelete, ID: 21, Rule: RuleDefinition{MappingField: MappingFieldBookingText, Regex: "gehalt"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, DiffCategorySet(existing, set, tt.mode))
+		})
+	}
+}
+
+func Test_DecodeCategorySet(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  Format
+		want    *CategorySet
+		wantErr bool
+	}{
+		{
+			name: "should decode yaml",
+			input: `
+version: 1
+categories:
+  - name: Groceries
+    color: "#00ff00"
+    rules:
+      - mappingField: recipient
+        regex: lidl
+`,
+			format: FormatYAML,
+			want: &CategorySet{
+				Version: 1,
+				Categories: []CategoryDefinition{
+					{
+						Name:  "Groceries",
+						Color: utils.NewString("#00ff00"),
+						Rules: []RuleDefinition{{MappingField: MappingFieldRecipient, Regex: "lidl"}},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:    "should decode json",
+			input:   `{"version": 1, "categories": [{"name": "Income"}]}`,
+			format:  FormatJSON,
+			want:    &CategorySet{Version: 1, Categories: []CategoryDefinition{{Name: "Income"}}},
+			wantErr: false,
+		},
+		{
+			name:    "should reject unknown versions",
+			input:   `{"version": 2, "categories": []}`,
+			format:  FormatJSON,
+			wantErr: true,
+		},
+		{
+			name:    "should reject unknown mapping fields",
+			input:   `{"version": 1, "categories": [{"name": "Income", "rules": [{"mappingField": "amount", "regex": "100"}]}]}`,
+			format:  FormatJSON,
+			wantErr: true,
+		},
+		{
+			name:    "should reject invalid regular expressions",
+			input:   `{"version": 1, "categories": [{"name": "Income", "rules": [{"mappingField": "purpose", "regex": "("}]}]}`,
+			format:  FormatJSON,
+			wantErr: true,
+		},
+		{
+			name:    "should reject duplicate categories",
+			input:   `{"version": 1, "categories": [{"name": "Income"}, {"name": "Income"}]}`,
+			format:  FormatJSON,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCategorySet(strings.NewReader(tt.input), tt.format)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidCategorySet)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_CategorySet_Transfer(t *testing.T) {
+	existing := []Category{
+		{ID: 1, Name: "Savings", Transfer: true},
+		{ID: 2, Name: "Groceries"},
+	}
+
+	// an exported and imported category set keeps the transfer flags
+	var exported strings.Builder
+	assert.NoError(t, EncodeCategorySet(&exported, NewCategorySet(existing), FormatYAML))
+	set, err := DecodeCategorySet(strings.NewReader(exported.String()), FormatYAML)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.NewBool(true), set.Categories[0].Transfer)
+	assert.Nil(t, set.Categories[1].Transfer)
+
+	for _, mode := range []ImportMode{ImportModeMerge, ImportModeReplace} {
+		assert.Empty(t, DiffCategorySet(existing, set, mode).Categories, "changes when importing with %s", mode)
+	}
+
+	// replacing removes omitted transfer flags, merging keeps them
+	set.Categories[0].Transfer = nil
+	assert.Equal(t, []CategoryChange{
+		{Action: ChangeActionUpdate, ID: 1, Name: "Savings", Rules: []RuleChange{}},
+	}, DiffCategorySet(existing, set, ImportModeReplace).Categories)
+	assert.Empty(t, DiffCategorySet(existing, set, ImportModeMerge).Categories)
+}
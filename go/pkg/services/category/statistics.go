@@ -0,0 +1,95 @@
+package category
+
+import "time"
+
+type DeadRuleReason string
+
+const (
+	// DeadRuleReasonUnused marks rules that did not match any
+	// transaction booked within the inspected period.
+	DeadRuleReasonUnused DeadRuleReason = "unused"
+	// DeadRuleReasonShadowed marks rules that matched transactions, but
+	// every one of them was assigned by an earlier evaluated rule of another category.
+	DeadRuleReasonShadowed DeadRuleReason = "shadowed"
+)
+
+type DeadRule struct {
+	Rule     CategoryRule   `json:"rule"`
+	Category Category       `json:"category"`
+	Reason   DeadRuleReason `json:"reason"`
+}
+
+// RuleHits collects the rule statistics of matched transactions,
+// so they can be persisted at once after an import or re-categorization.
+type RuleHits struct {
+	hits     map[int64]int64
+	shadowed map[int64]int64
+	lastHit  map[int64]time.Time
+}
+
+func NewRuleHits() *RuleHits {
+	return &RuleHits{
+		hits:     map[int64]int64{},
+		shadowed: map[int64]int64{},
+		lastHit:  map[int64]time.Time{},
+	}
+}
+
+// Hit records that the rule assigned its category to a transaction booked at the passed date.
+func (h *RuleHits) Hit(ruleID int64, bookingDate time.Time) {
+	h.hits[ruleID]++
+	if last, ok := h.lastHit[ruleID]; !ok || bookingDate.After(last) {
+		h.lastHit[ruleID] = bookingDate
+	}
+}
+
+// Shadow records that the rule matched a transaction, but a rule of another category won.
+func (h *RuleHits) Shadow(ruleID int64) {
+	h.shadowed[ruleID]++
+}
+
+// Merge adds all statistics of the passed rule hits.
+func (h *RuleHits) Merge(other *RuleHits) {
+	for ruleID, count := range other.hits {
+		h.hits[ruleID] += count
+	}
+	for ruleID, count := range other.shadowed {
+		h.shadowed[ruleID] += count
+	}
+	for ruleID, date := range other.lastHit {
+		if last, ok := h.lastHit[ruleID]; !ok || date.After(last) {
+			h.lastHit[ruleID] = date
+		}
+	}
+}
+
+func (h *RuleHits) HitCount(ruleID int64) int64 {
+	return h.hits[ruleID]
+}
+
+func (h *RuleHits) ShadowedCount(ruleID int64) int64 {
+	return h.shadowed[ruleID]
+}
+
+func (h *RuleHits) LastHitDate(ruleID int64) *time.Time {
+	if date, ok := h.lastHit[ruleID]; ok {
+		return &date
+	}
+	return nil
+}
+
+func (h *RuleHits) Empty() bool {
+	return len(h.hits) == 0 && len(h.shadowed) == 0
+}
+
+// DeadReason returns why the rule is considered dead, or an empty
+// reason if it assigned its category since the passed date.
+func (r *CategoryRule) DeadReason(since time.Time) DeadRuleReason {
+	if r.HitCount == 0 && r.ShadowedCount > 0 {
+		return DeadRuleReasonShadowed
+	}
+	if r.LastHitDate == nil || r.LastHitDate.Before(since) {
+		return DeadRuleReasonUnused
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package category
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CategoryRule_DeadReason(t *testing.T) {
+	since := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	recently := time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC)
+	longAgo := time.Date(2022, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rule CategoryRule
+		want DeadRuleReason
+	}{
+		{
+			name: "should be alive with recent hits",
+			rule: CategoryRule{HitCount: 3, ShadowedCount: 1, LastHitDate: &recently},
+			want: "",
+		},
+		{
+			name: "should be unused without any matches",
+			rule: CategoryRule{},
+			want: DeadRuleReasonUnused,
+		},
+		{
+			name: "should be unused with old hits only",
+			rule: CategoryRule{HitCount: 12, LastHitDate: &longAgo},
+			want: DeadRuleReasonUnused,
+		},
+		{
+			name: "should be shadowed if it never won",
+			rule: CategoryRule{ShadowedCount: 5},
+			want: DeadRuleReasonShadowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule.DeadReason(since))
+		})
+	}
+}
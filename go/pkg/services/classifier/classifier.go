@@ -0,0 +1,254 @@
+package classifier
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/lock"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+)
+
+type Suggestion struct {
+	Category   category.Category `json:"category"`
+	Confidence float64           `json:"confidence"`
+}
+
+type TransactionSuggestions struct {
+	Transaction transaction.Transaction `json:"transaction"`
+	Suggestions []Suggestion            `json:"suggestions"`
+}
+
+type AutoAssignResult struct {
+	Assigned []TransactionSuggestions `json:"assigned"`
+	// Locked is the number of transactions skipped, as they are booked within a locked period.
+	Locked int `json:"locked"`
+}
+
+// SelectAssignments returns the suggestions, whose most likely category has at least the passed
+// confidence. Transactions booked within one of the locks are skipped and only counted.
+func SelectAssignments(suggestions []TransactionSuggestions, locks []lock.Lock, threshold float64) ([]TransactionSuggestions, int) {
+	selected := make([]TransactionSuggestions, 0)
+	locked := 0
+	for _, suggestion := range suggestions {
+		if len(suggestion.Suggestions) == 0 || suggestion.Suggestions[0].Confidence < threshold {
+			continue
+		}
+		if lock.Covering(locks, suggestion.Transaction.BookingDate) != nil {
+			locked++
+			continue
+		}
+		selected = append(selected, suggestion)
+	}
+	return selected, locked
+}
+
+// RestrictSuggestions returns the k most likely of the suggestions, whose category is one of the
+// passed categories. The suggested categories are replaced with the passed ones, which are current.
+func RestrictSuggestions(suggestions []Suggestion, categories []category.Category, k int) []Suggestion {
+	current := make(map[int64]category.Category, len(categories))
+	for _, c := range categories {
+		current[c.ID] = c
+	}
+
+	restricted := make([]Suggestion, 0)
+	for _, suggestion := range suggestions {
+		if len(restricted) >= k {
+			break
+		}
+		c, ok := current[suggestion.Category.ID]
+		if !ok {
+			continue
+		}
+		suggestion.Category = c
+		restricted = append(restricted, suggestion)
+	}
+	return restricted
+}
+
+type TrainingSummary struct {
+	Documents  int       `json:"documents"`
+	Categories int       `json:"categories"`
+	Vocabulary int       `json:"vocabulary"`
+	TrainedAt  time.Time `json:"trainedAt"`
+}
+
+// Model is a multinomial naive Bayes classifier over the tokens of the
+// recipient, booking text and purpose of a transaction and a bucket of its amount.
+type Model struct {
+	categories     map[int64]category.Category
+	documents      map[int64]int
+	tokenCounts    map[int64]map[string]int
+	tokenTotals    map[int64]int
+	vocabulary     map[string]struct{}
+	totalDocuments int
+	trainedAt      time.Time
+}
+
+func NewModel() *Model {
+	return &Model{
+		categories:  map[int64]category.Category{},
+		documents:   map[int64]int{},
+		tokenCounts: map[int64]map[string]int{},
+		tokenTotals: map[int64]int{},
+		vocabulary:  map[string]struct{}{},
+	}
+}
+
+// Train adds the passed categorized transactions to the model. Transactions
+// without a category are ignored.
+func (m *Model) Train(transactions []transaction.Transaction) {
+	for _, t := range transactions {
+		if t.Category == nil {
+			continue
+		}
+		categoryID := t.Category.ID
+
+		m.categories[categoryID] = category.Category{
+			ID:          t.Category.ID,
+			Name:        t.Category.Name,
+			Description: t.Category.Description,
+			Color:       t.Category.Color,
+		}
+		m.documents[categoryID]++
+		m.totalDocuments++
+
+		if _, ok := m.tokenCounts[categoryID]; !ok {
+			m.tokenCounts[categoryID] = map[string]int{}
+		}
+		for _, token := range Tokenize(&t) {
+			m.tokenCounts[categoryID][token]++
+			m.tokenTotals[categoryID]++
+			m.vocabulary[token] = struct{}{}
+		}
+	}
+	m.trainedAt = time.Now().UTC()
+}
+
+func (m *Model) Summary() TrainingSummary {
+	return TrainingSummary{
+		Documents:  m.totalDocuments,
+		Categories: len(m.categories),
+		Vocabulary: len(m.vocabulary),
+		TrainedAt:  m.trainedAt,
+	}
+}
+
+// Predict returns the k most likely categories for the passed transaction, ordered
+// by descending confidence. The confidences of all known categories sum up to 1.
+func (m *Model) Predict(t *transaction.Transaction, k int) []Suggestion {
+	if m.totalDocuments == 0 || k <= 0 {
+		return []Suggestion{}
+	}
+
+	tokens := make([]string, 0)
+	for _, token := range Tokenize(t) {
+		// tokens never seen during training carry no information
+		if _, ok := m.vocabulary[token]; ok {
+			tokens = append(tokens, token)
+		}
+	}
+
+	vocabularySize := float64(len(m.vocabulary))
+	scores := make(map[int64]float64, len(m.categories))
+	maxScore := math.Inf(-1)
+	for categoryID := range m.categories {
+		// log prior plus the laplace smoothed log likelihood of every token
+		score := math.Log(float64(m.documents[categoryID]) / float64(m.totalDocuments))
+		denominator := float64(m.tokenTotals[categoryID]) + vocabularySize
+		for _, token := range tokens {
+			score += math.Log((float64(m.tokenCounts[categoryID][token]) + 1) / denominator)
+		}
+
+		scores[categoryID] = score
+		if score > maxScore {
+			maxScore = score
+		}
+	}
+
+	// normalize the log scores to probabilities with a numerically stable softmax
+	var sum float64
+	for categoryID, score := range scores {
+		scores[categoryID] = math.Exp(score - maxScore)
+		sum += scores[categoryID]
+	}
+
+	suggestions := make([]Suggestion, 0, len(scores))
+	for categoryID, score := range scores {
+		suggestions = append(suggestions, Suggestion{
+			Category:   m.categories[categoryID],
+			Confidence: score / sum,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence == suggestions[j].Confidence {
+			return suggestions[i].Category.ID < suggestions[j].Category.ID
+		}
+		return suggestions[i].Confidence > suggestions[j].Confidence
+	})
+
+	if len(suggestions) > k {
+		suggestions = suggestions[:k]
+	}
+	return suggestions
+}
+
+// Tokenize splits the descriptive fields of a transaction into lower-cased word tokens
+// prefixed with their field, so that e.g. "gehalt" in the booking text and in the
+// purpose are different features. Pure numbers and masked card numbers are dropped, as
+// they are mostly unique references. Finally, a token for the amount bucket is added.
+func Tokenize(t *transaction.Transaction) []string {
+	tokens := make([]string, 0)
+	if t.Recipient != nil {
+		tokens = append(tokens, tokenizeField("recipient", *t.Recipient)...)
+	}
+	tokens = append(tokens, tokenizeField("booking_text", t.BookingText)...)
+	if t.Purpose != nil {
+		tokens = append(tokens, tokenizeField("purpose", *t.Purpose)...)
+	}
+	tokens = append(tokens, amountBucket(t.Amount))
+	return tokens
+}
+
+func tokenizeField(field string, value string) []string {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) < 2 || isNumber(word) || strings.Trim(word, "x") == "" {
+			continue
+		}
+		tokens = append(tokens, fmt.Sprintf("%s:%s", field, word))
+	}
+	return tokens
+}
+
+// amountBucket groups amounts by sign and half orders of magnitude, e.g.
+// all amounts from -3.16 to -9.99 end up in the same bucket.
+func amountBucket(amount float64) string {
+	sign := "+"
+	if amount < 0 {
+		sign = "-"
+	}
+
+	absolute := math.Abs(amount)
+	if absolute < 1 {
+		return fmt.Sprintf("amount:%s0", sign)
+	}
+	return fmt.Sprintf("amount:%s%d", sign, int(math.Floor(math.Log10(absolute)*2))+1)
+}
+
+func isNumber(value string) bool {
+	for _, r := range value {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
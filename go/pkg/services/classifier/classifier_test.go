@@ -0,0 +1,229 @@
+package classifier
+
+import (
+	"testing"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/lock"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Model_Predict(t *testing.T) {
+	categoryGroceries := category.Category{ID: 1, Name: "Groceries"}
+	categoryIncome := category.Category{ID: 2, Name: "Income"}
+	categoryMobility := category.Category{ID: 3, Name: "Mobility"}
+
+	model := NewModel()
+	model.Train([]transaction.Transaction{
+		{
+			Recipient:   utils.NewString("VISA KAUFLAND MONSCHAU 8710"),
+			BookingText: "Lastschrift",
+			Purpose:     utils.NewString("NR XXXX 0815 MONSCHAU Apple Pay"),
+			Amount:      -13.37,
+			Category:    &categoryGroceries,
+		},
+		{
+			Recipient:   utils.NewString("VISA LIDL DIENSTLEISTUNG"),
+			BookingText: "Lastschrift",
+			Purpose:     utils.NewString("NR XXXX 1337 MONSCHAU KAUFUMSATZ 01.01 0815123"),
+			Amount:      -42.10,
+			Category:    &categoryGroceries,
+		},
+		{
+			Recipient:   utils.NewString("ACME AG"),
+			BookingText: "Gehalt/Rente",
+			Purpose:     utils.NewString("Abrechnung 2023/01"),
+			Amount:      3300.42,
+			Category:    &categoryIncome,
+		},
+		{
+			Recipient:   utils.NewString("AUTO BANK AG NL Deutschland"),
+			BookingText: "Lastschrift",
+			Purpose:     utils.NewString("Auto Leasing/VT12345678 05/23 Rate"),
+			Amount:      -69.42,
+			Category:    &categoryMobility,
+		},
+		{
+			Recipient:   utils.NewString("Jan Muster"),
+			BookingText: "Überweisung",
+			Amount:      -29,
+		},
+	})
+
+	tests := []struct {
+		name         string
+		transaction  transaction.Transaction
+		k            int
+		wantCategory *category.Category
+		wantCount    int
+	}{
+		{
+			name: "should suggest groceries",
+			transaction: transaction.Transaction{
+				Recipient:   utils.NewString("VISA KAUFLAND FILIALE 1234"),
+				BookingText: "Lastschrift",
+				Purpose:     utils.NewString("NR XXXX 0815 MONSCHAU KAUFUMSATZ"),
+				Amount:      -21.50,
+			},
+			k:            3,
+			wantCategory: &categoryGroceries,
+			wantCount:    3,
+		},
+		{
+			name: "should suggest income",
+			transaction: transaction.Transaction{
+				Recipient:   utils.NewString("ACME AG"),
+				BookingText: "Gehalt/Rente",
+				Purpose:     utils.NewString("Abrechnung 2023/02"),
+				Amount:      3300.42,
+			},
+			k:            1,
+			wantCategory: &categoryIncome,
+			wantCount:    1,
+		},
+		{
+			name: "should return no suggestions for k = 0",
+			transaction: transaction.Transaction{
+				Recipient:   utils.NewString("ACME AG"),
+				BookingText: "Gehalt/Rente",
+			},
+			k:            0,
+			wantCategory: nil,
+			wantCount:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggestions := model.Predict(&tt.transaction, tt.k)
+			assert.Len(t, suggestions, tt.wantCount)
+			if tt.wantCategory == nil {
+				return
+			}
+
+			assert.Equal(t, tt.wantCategory.ID, suggestions[0].Category.ID)
+			assert.Greater(t, suggestions[0].Confidence, 0.5)
+			for i := 1; i < len(suggestions); i++ {
+				assert.GreaterOrEqual(t, suggestions[i-1].Confidence, suggestions[i].Confidence)
+			}
+		})
+	}
+}
+
+func Test_Tokenize(t *testing.T) {
+	tests := []struct {
+		name        string
+		transaction transaction.Transaction
+		want        []string
+	}{
+		{
+			name: "should tokenize all fields",
+			transaction: transaction.Transaction{
+				Recipient:   utils.NewString("VISA KAUFLAND MONSCHAU 8710"),
+				BookingText: "Lastschrift",
+				Purpose:     utils.NewString("NR XXXX 0815 Apple Pay"),
+				Amount:      -13.37,
+			},
+			want: []string{
+				"recipient:visa",
+				"recipient:kaufland",
+				"recipient:monschau",
+				"booking_text:lastschrift",
+				"purpose:nr",
+				"purpose:apple",
+				"purpose:pay",
+				"amount:-3",
+			},
+		},
+		{
+			name: "should skip missing fields",
+			transaction: transaction.Transaction{
+				BookingText: "Gutschrift",
+				Amount:      150,
+			},
+			want: []string{
+				"booking_text:gutschrift",
+				"amount:+5",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Tokenize(&tt.transaction))
+		})
+	}
+}
+
+func Test_SelectAssignments(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	suggestion := func(id int64, bookingDate time.Time, confidence float64) TransactionSuggestions {
+		return TransactionSuggestions{
+			Transaction: transaction.Transaction{ID: id, BookingDate: bookingDate},
+			Suggestions: []Suggestion{{Category: category.Category{ID: 1}, Confidence: confidence}},
+		}
+	}
+
+	suggestions := []TransactionSuggestions{
+		suggestion(1, date(1, 10), 0.9),
+		suggestion(2, date(2, 10), 0.9),
+		suggestion(3, date(2, 11), 0.5),
+		{Transaction: transaction.Transaction{ID: 4, BookingDate: date(2, 12)}},
+		suggestion(5, date(1, 31), 0.4),
+	}
+	locks := []lock.Lock{
+		{Start: date(1, 1), End: date(1, 31)},
+	}
+
+	selected, locked := SelectAssignments(suggestions, locks, 0.8)
+	assert.Equal(t, []TransactionSuggestions{suggestions[1]}, selected)
+	assert.Equal(t, 1, locked)
+}
+
+func Test_RestrictSuggestions(t *testing.T) {
+	// the model was trained before groceries were merged into food and mobility was renamed
+	suggestions := []Suggestion{
+		{Category: category.Category{ID: 1, Name: "Groceries"}, Confidence: 0.6},
+		{Category: category.Category{ID: 2, Name: "Mobility"}, Confidence: 0.3},
+		{Category: category.Category{ID: 3, Name: "Food"}, Confidence: 0.1},
+	}
+	categories := []category.Category{
+		{ID: 2, Name: "Car"},
+		{ID: 3, Name: "Food"},
+	}
+
+	tests := []struct {
+		name string
+		k    int
+		want []Suggestion
+	}{
+		{
+			name: "should skip missing categories",
+			k:    1,
+			want: []Suggestion{{Category: categories[0], Confidence: 0.3}},
+		},
+		{
+			name: "should return at most the existing categories",
+			k:    3,
+			want: []Suggestion{
+				{Category: categories[0], Confidence: 0.3},
+				{Category: categories[1], Confidence: 0.1},
+			},
+		},
+		{
+			name: "should return nothing without k",
+			k:    0,
+			want: []Suggestion{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RestrictSuggestions(suggestions, categories, tt.k))
+		})
+	}
+}
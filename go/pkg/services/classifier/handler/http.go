@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/classifier"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSuggestionCount = 3
+
+type Handler struct {
+	Service         *classifier.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         classifier.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	classifierAPI := router.Group("/classifier")
+	classifierAPI.POST("/train", handler.Train)
+	classifierAPI.GET("/suggestions", handler.Suggest)
+	classifierAPI.POST("/apply", handler.Apply)
+
+	return handler
+}
+
+func (h *Handler) Train(c *gin.Context) {
+	// train over all categorized transactions, if no range is passed
+	from, to, err := h.IntervalService.Range(
+		c.Query("period"),
+		c.DefaultQuery("from", time.Time{}.Format(time.DateOnly)),
+		c.DefaultQuery("to", time.Now().Format(time.DateOnly)),
+	)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	summary, err := h.Service.Train(from, to)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+func (h *Handler) Suggest(c *gin.Context) {
+	from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	k := defaultSuggestionCount
+	if rawK := c.Query("k"); rawK != "" {
+		k, err = strconv.Atoi(rawK)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	suggestions, err := h.Service.Suggest(from, to, k)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+func (h *Handler) Apply(c *gin.Context) {
+	from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	threshold, err := strconv.ParseFloat(c.Query("threshold"), 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Service.AutoAssign(from, to, threshold)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	if len(result.Assigned) > 0 {
+		// categorized incomes may move the start of the stored fiscal months
+		err = h.IntervalService.InvalidateFiscalMonths(from, to)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, classifier.ErrInvalidThreshold),
+		errors.Is(err, interval.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/classifier"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Handler_InvalidPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// invalid ranges are rejected before the database is used
+	handler := &Handler{
+		Service:         classifier.NewService(nil),
+		IntervalService: interval.NewService(nil),
+	}
+
+	tests := []struct {
+		name   string
+		handle gin.HandlerFunc
+		query  string
+	}{
+		{name: "should reject an invalid period when training", handle: handler.Train, query: "?period=someday"},
+		{name: "should reject an invalid date when training", handle: handler.Train, query: "?from=2024-13-01"},
+		{name: "should reject an invalid period when suggesting", handle: handler.Suggest, query: "?period=someday"},
+		{name: "should reject a missing range when suggesting", handle: handler.Suggest, query: ""},
+		{name: "should reject an invalid period when applying", handle: handler.Apply, query: "?period=someday&threshold=0.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/classifier"+tt.query, nil)
+
+			tt.handle(c)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
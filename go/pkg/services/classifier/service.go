@@ -0,0 +1,158 @@
+package classifier
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/lock"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"docqube.de/bookkeeper/pkg/utils"
+)
+
+var (
+	ErrInvalidThreshold = errors.New("threshold must be greater than 0 and at most 1")
+)
+
+var (
+	modelCache      *Model
+	modelCacheMutex sync.RWMutex
+)
+
+type Service struct {
+	categoryService    *category.Service
+	transactionService *transaction.Service
+	lockService        *lock.Service
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		categoryService:    category.NewService(db),
+		transactionService: transaction.NewService(db),
+		lockService:        lock.NewService(db),
+	}
+}
+
+// Train builds a new model from all categorized transactions booked between
+// from and to and replaces the currently used model.
+func (s *Service) Train(from, to time.Time) (*TrainingSummary, error) {
+	transactions, err := s.transactionService.List(transaction.Filter{
+		From:       &from,
+		To:         &to,
+		Classified: true,
+		Hidden:     utils.NewBool(false),
+	}, transaction.Chronological, transaction.Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	model := NewModel()
+	model.Train(transactions.Items)
+
+	modelCacheMutex.Lock()
+	modelCache = model
+	modelCacheMutex.Unlock()
+
+	summary := model.Summary()
+	return &summary, nil
+}
+
+// Suggest returns the k most likely categories for every unclassified transaction booked
+// between from and to. The model may have been trained before categories were merged, deleted
+// or archived, so only categories, which still exist and aren't archived, are suggested.
+func (s *Service) Suggest(from, to time.Time, k int) ([]TransactionSuggestions, error) {
+	model, err := s.model()
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryService.List(false)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := s.transactionService.List(transaction.Filter{
+		From:          &from,
+		To:            &to,
+		Uncategorized: true,
+		Hidden:        utils.NewBool(false),
+	}, transaction.Chronological, transaction.Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := make([]TransactionSuggestions, 0, len(transactions.Items))
+	for _, t := range transactions.Items {
+		suggestions = append(suggestions, TransactionSuggestions{
+			Transaction: t,
+			Suggestions: RestrictSuggestions(model.Predict(&t, math.MaxInt), categories, k),
+		})
+	}
+	return suggestions, nil
+}
+
+// AutoAssign categorizes every unclassified transaction booked between from and to
+// with its most likely category, if the confidence is at least the passed threshold.
+// The categorized transactions are returned together with the applied suggestion.
+// Transactions booked within a locked period keep their category and are only counted.
+func (s *Service) AutoAssign(from, to time.Time, threshold float64) (*AutoAssignResult, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, ErrInvalidThreshold
+	}
+
+	suggestions, err := s.Suggest(from, to, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := s.lockService.List()
+	if err != nil {
+		return nil, err
+	}
+
+	selected, locked := SelectAssignments(suggestions, locks, threshold)
+	result := AutoAssignResult{
+		Assigned: make([]TransactionSuggestions, 0, len(selected)),
+		Locked:   locked,
+	}
+	for _, suggestion := range selected {
+		category := suggestion.Suggestions[0].Category
+		err = s.transactionService.Categorize(audit.Classifier, suggestion.Transaction.ID, category.ID)
+		if errors.Is(err, lock.ErrPeriodLocked) {
+			// the period was locked in the meantime
+			result.Locked++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		suggestion.Transaction.Category = &category
+		result.Assigned = append(result.Assigned, suggestion)
+	}
+	return &result, nil
+}
+
+// model returns the cached model and trains a new one over
+// all categorized transactions, if there is none yet.
+func (s *Service) model() (*Model, error) {
+	modelCacheMutex.RLock()
+	model := modelCache
+	modelCacheMutex.RUnlock()
+	if model != nil {
+		return model, nil
+	}
+
+	_, err := s.Train(time.Time{}, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	modelCacheMutex.RLock()
+	defer modelCacheMutex.RUnlock()
+	return modelCache, nil
+}
@@ -0,0 +1,97 @@
+package forecast
+
+import (
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/subscription"
+)
+
+// Payment is a recurring payment or income expected on a day of the forecast.
+type Payment struct {
+	SubscriptionID int64   `json:"subscriptionID"`
+	Recipient      string  `json:"recipient"`
+	Amount         float64 `json:"amount"`
+	// DueDate is the nominal date of the payment, it is booked on the next or previous business day.
+	DueDate time.Time `json:"dueDate"`
+}
+
+// Day is the projected account balance at the end of a day.
+type Day struct {
+	Date     time.Time `json:"date"`
+	Amount   float64   `json:"amount"`
+	Balance  float64   `json:"balance"`
+	Payments []Payment `json:"payments"`
+}
+
+type Forecast struct {
+	// BalanceDate is the booking date of the latest transaction providing the start balance.
+	BalanceDate       time.Time `json:"balanceDate"`
+	StartBalance      float64   `json:"startBalance"`
+	Start             time.Time `json:"start"`
+	End               time.Time `json:"end"`
+	EndBalance        float64   `json:"endBalance"`
+	LowestBalance     float64   `json:"lowestBalance"`
+	LowestBalanceDate time.Time `json:"lowestBalanceDate"`
+	Days              []Day     `json:"days"`
+}
+
+// maxShift is the number of days a payment due after the end of the forecast may be booked earlier.
+const maxShift = 7
+
+// Project projects the balance day by day from start to end using the expected payments
+// of the passed subscriptions. Payments are booked on the business day of the calendar
+// they are expected on. Expected payments before the start are assumed to be
+// skipped and are not projected, so missing payments don't distort the forecast.
+func Project(startBalance float64, start, end time.Time, subscriptions []subscription.Subscription, cal *calendar.Calendar) Forecast {
+	// payments are keyed by their day, as dates read from the database aren't located in UTC
+	payments := make(map[string][]Payment)
+	for _, s := range subscriptions {
+		for date := s.NextDate; !date.After(end.AddDate(0, 0, maxShift)); date = s.Interval.Next(date) {
+			bookingDate := subscription.BookingDate(cal, date, s.Amount)
+			if bookingDate.Before(start) || bookingDate.After(end) {
+				continue
+			}
+			key := bookingDate.Format(time.DateOnly)
+			payments[key] = append(payments[key], Payment{
+				SubscriptionID: s.ID,
+				Recipient:      s.Recipient,
+				Amount:         s.Amount,
+				DueDate:        date,
+			})
+		}
+	}
+
+	forecast := Forecast{
+		StartBalance:      startBalance,
+		Start:             start,
+		End:               end,
+		EndBalance:        startBalance,
+		LowestBalance:     startBalance,
+		LowestBalanceDate: start,
+		Days:              make([]Day, 0),
+	}
+
+	balance := startBalance
+	for date := start; !date.After(end); date = date.AddDate(0, 0, 1) {
+		day := Day{
+			Date:     date,
+			Payments: make([]Payment, 0),
+		}
+		for _, payment := range payments[date.Format(time.DateOnly)] {
+			day.Amount += payment.Amount
+			day.Payments = append(day.Payments, payment)
+		}
+
+		balance += day.Amount
+		day.Balance = balance
+		if balance < forecast.LowestBalance {
+			forecast.LowestBalance = balance
+			forecast.LowestBalanceDate = date
+		}
+		forecast.Days = append(forecast.Days, day)
+	}
+	forecast.EndBalance = balance
+
+	return forecast
+}
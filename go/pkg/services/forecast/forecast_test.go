@@ -0,0 +1,68 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/subscription"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_Project(t *testing.T) {
+	subscriptions := []subscription.Subscription{
+		{ID: 1, Recipient: "Rent", Interval: subscription.IntervalMonthly, Amount: -900, NextDate: date(2024, 5, 3)},
+		{ID: 2, Recipient: "Gym", Interval: subscription.IntervalWeekly, Amount: -10, NextDate: date(2024, 5, 2)},
+		{ID: 3, Recipient: "Salary", Interval: subscription.IntervalMonthly, Amount: 2000, NextDate: date(2024, 5, 5)},
+		// missing payments before the start are not projected
+		{ID: 4, Recipient: "Cancelled", Interval: subscription.IntervalYearly, Amount: -50, NextDate: date(2024, 4, 1)},
+	}
+
+	got := Project(1000, date(2024, 5, 1), date(2024, 5, 10), subscriptions, calendar.New(calendar.StateNone))
+
+	assert.Len(t, got.Days, 10)
+	assert.Equal(t, 1000.0, got.Days[0].Balance)
+	assert.Equal(t, []Payment{{SubscriptionID: 2, Recipient: "Gym", Amount: -10, DueDate: date(2024, 5, 2)}}, got.Days[1].Payments)
+	// the salary due on sunday is paid on friday
+	assert.Equal(t, 2090.0, got.Days[2].Balance)
+	assert.Equal(t, 2090.0, got.Days[4].Balance)
+	// ascension day is no business day, so the gym is debited on friday
+	assert.Equal(t, 2090.0, got.Days[8].Balance)
+	assert.Equal(t, 2080.0, got.Days[9].Balance)
+	assert.Equal(t, 2080.0, got.EndBalance)
+	assert.Equal(t, 990.0, got.LowestBalance)
+	assert.Equal(t, date(2024, 5, 2), got.LowestBalanceDate)
+}
+
+func Test_Project_ShiftedBeyondEnd(t *testing.T) {
+	subscriptions := []subscription.Subscription{
+		// due after the end, but paid on the friday before
+		{ID: 1, Recipient: "Salary", Interval: subscription.IntervalMonthly, Amount: 2000, NextDate: date(2024, 5, 26)},
+		// due within the forecast, but debited on the monday after the end
+		{ID: 2, Recipient: "Rent", Interval: subscription.IntervalMonthly, Amount: -900, NextDate: date(2024, 5, 25)},
+	}
+
+	got := Project(0, date(2024, 5, 1), date(2024, 5, 25), subscriptions, calendar.New(calendar.StateNone))
+
+	assert.Equal(t, 2000.0, got.EndBalance)
+	assert.Equal(t, []Payment{{SubscriptionID: 1, Recipient: "Salary", Amount: 2000, DueDate: date(2024, 5, 26)}}, got.Days[23].Payments)
+}
+
+func Test_Project_DatabaseLocation(t *testing.T) {
+	// lib/pq returns dates in a fixed zone instead of UTC
+	location := time.FixedZone("", 0)
+	subscriptions := []subscription.Subscription{
+		{ID: 1, Recipient: "Rent", Interval: subscription.IntervalMonthly, Amount: -900, NextDate: time.Date(2024, 5, 3, 0, 0, 0, 0, location)},
+		{ID: 2, Recipient: "Salary", Interval: subscription.IntervalMonthly, Amount: 2000, NextDate: time.Date(2024, 5, 5, 0, 0, 0, 0, location)},
+	}
+
+	got := Project(0, date(2024, 5, 1), date(2024, 5, 10), subscriptions, calendar.New(calendar.StateNone))
+
+	assert.Equal(t, -900.0, got.Days[2].Payments[0].Amount)
+	assert.Equal(t, 1100.0, got.Days[2].Balance)
+	assert.Equal(t, 1100.0, got.EndBalance)
+}
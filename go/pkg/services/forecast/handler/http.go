@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/account"
+	"docqube.de/bookkeeper/pkg/services/forecast"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service *forecast.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service: forecast.NewService(db),
+	}
+
+	router.GET("/forecast", handler.Forecast)
+
+	return handler
+}
+
+// Forecast projects the balance of the passed account or the default account for the passed
+// number of months or, if no months are passed, until the end of the current fiscal month.
+func (h *Handler) Forecast(c *gin.Context) {
+	var (
+		result    *forecast.Forecast
+		accountID int64
+		err       error
+	)
+	if rawAccountID := c.Query("account_id"); rawAccountID != "" {
+		accountID, err = strconv.ParseInt(rawAccountID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if rawMonths := c.Query("months"); rawMonths != "" {
+		var months int
+		months, err = strconv.Atoi(rawMonths)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		result, err = h.Service.ForecastMonths(accountID, time.Now(), months)
+	} else {
+		// the income category overrides the configured fiscal settings
+		var incomeCategoryID int64
+		if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+			incomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		result, err = h.Service.ForecastFiscalMonth(accountID, time.Now(), incomeCategoryID)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, forecast.ErrInvalidMonths):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, account.ErrAccountNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, transaction.ErrTransactionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "no transactions to forecast from"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/forecast"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Handler_Forecast_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// invalid queries are rejected before the database is used
+	handler := &Handler{Service: forecast.NewService(nil)}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "should reject an invalid account", query: "?account_id=main"},
+		{name: "should reject an invalid account with months", query: "?account_id=main&months=3"},
+		{name: "should reject non-positive months", query: "?account_id=1&months=0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/forecast"+tt.query, nil)
+
+			handler.Forecast(c)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package forecast
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/account"
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/subscription"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+)
+
+var (
+	ErrInvalidMonths = errors.New("months must be positive")
+)
+
+type Service struct {
+	accountService      *account.Service
+	calendarService     *calendar.Service
+	intervalService     *interval.Service
+	subscriptionService *subscription.Service
+	transactionService  *transaction.Service
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		accountService:      account.NewService(db),
+		calendarService:     calendar.NewService(db),
+		intervalService:     interval.NewService(db),
+		subscriptionService: subscription.NewService(db),
+		transactionService:  transaction.NewService(db),
+	}
+}
+
+// ForecastFiscalMonth projects the balance of the account from today until the end
+// of the fiscal month containing today. Without an account, the default account is used.
+func (s *Service) ForecastFiscalMonth(accountID int64, now time.Time, incomeCategoryID int64) (*Forecast, error) {
+	today := truncateToDay(now)
+	fiscalMonth, err := s.intervalService.GetFiscalMonthOfDate(today, incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+	return s.forecast(accountID, today, fiscalMonth.End)
+}
+
+// ForecastMonths projects the balance of the account from today until the same day the passed number
+// of months ahead. Without an account, the default account is used.
+func (s *Service) ForecastMonths(accountID int64, now time.Time, months int) (*Forecast, error) {
+	if months <= 0 {
+		return nil, ErrInvalidMonths
+	}
+	today := truncateToDay(now)
+	return s.forecast(accountID, today, today.AddDate(0, months, 0))
+}
+
+func (s *Service) forecast(accountID int64, start, end time.Time) (*Forecast, error) {
+	var (
+		forecastAccount *account.Account
+		err             error
+	)
+	if accountID == 0 {
+		forecastAccount, err = s.accountService.Default()
+	} else {
+		forecastAccount, err = s.accountService.Get(accountID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.transactionService.LatestBalance(forecastAccount.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	subscriptions, err := s.subscriptionService.List(start)
+	if err != nil {
+		return nil, err
+	}
+
+	cal, err := s.calendarService.Calendar()
+	if err != nil {
+		return nil, err
+	}
+
+	forecast := Project(balance.Balance, start, end, subscriptions, cal)
+	forecast.BalanceDate = balance.Date
+	return &forecast, nil
+}
+
+func truncateToDay(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,202 @@
+package interval
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+)
+
+var ErrInvalidFiscalSettings = errors.New("invalid fiscal settings")
+
+// FiscalStrategy defines how the start of a fiscal month is determined.
+type FiscalStrategy string
+
+const (
+	// FiscalStrategyCalendar uses the calendar months.
+	FiscalStrategyCalendar FiscalStrategy = "calendar"
+	// FiscalStrategyFixedDay starts every fiscal month on the same day of the month.
+	FiscalStrategyFixedDay FiscalStrategy = "fixed_day"
+	// FiscalStrategyPayday starts every fiscal month with the first income of the income categories.
+	FiscalStrategyPayday FiscalStrategy = "payday"
+)
+
+// Shift defines where a fixed day falling on a weekend is moved to.
+type Shift string
+
+const (
+	ShiftNone     Shift = "none"
+	ShiftPrevious Shift = "previous"
+	ShiftNext     Shift = "next"
+)
+
+// defaultToleranceDays is the number of days before the start of the calendar
+// month a fiscal month may start at the latest.
+const defaultToleranceDays = 15
+
+type FiscalSettings struct {
+	Strategy FiscalStrategy `json:"strategy"`
+	// Day is the day of the month fiscal months start with the fixed day strategy.
+	Day   int   `json:"day"`
+	Shift Shift `json:"shift"`
+	// ToleranceDays is the number of days before the start of the calendar month
+	// a fiscal month may start. Earlier days belong to the previous fiscal month. Fixed days
+	// within the tolerance of a month with 31 days start the fiscal month in the previous month.
+	ToleranceDays     int     `json:"toleranceDays"`
+	IncomeCategoryIDs []int64 `json:"incomeCategoryIDs"`
+	// YearStartMonth is the first fiscal month of a fiscal year, fiscal quarters start every three months.
+	YearStartMonth int `json:"yearStartMonth"`
+}
+
+// DefaultFiscalSettings returns the settings used until the household configured its own.
+func DefaultFiscalSettings() FiscalSettings {
+	return FiscalSettings{
+		Strategy:          FiscalStrategyPayday,
+		Shift:             ShiftNone,
+		ToleranceDays:     defaultToleranceDays,
+		IncomeCategoryIDs: []int64{},
+		YearStartMonth:    1,
+	}
+}
+
+func (s FiscalSettings) Validate() error {
+	switch s.Strategy {
+	case FiscalStrategyCalendar, FiscalStrategyPayday:
+	case FiscalStrategyFixedDay:
+		if s.Day < 1 || s.Day > 31 {
+			return fmt.Errorf("%w: day must be between 1 and 31", ErrInvalidFiscalSettings)
+		}
+	default:
+		return fmt.Errorf("%w: unknown strategy %s", ErrInvalidFiscalSettings, s.Strategy)
+	}
+
+	switch s.Shift {
+	case ShiftNone, ShiftPrevious, ShiftNext:
+	default:
+		return fmt.Errorf("%w: unknown shift %s", ErrInvalidFiscalSettings, s.Shift)
+	}
+
+	if s.ToleranceDays < 1 || s.ToleranceDays > 28 {
+		return fmt.Errorf("%w: tolerance days must be between 1 and 28", ErrInvalidFiscalSettings)
+	}
+	if s.YearStartMonth < 1 || s.YearStartMonth > 12 {
+		return fmt.Errorf("%w: year start month must be between 1 and 12", ErrInvalidFiscalSettings)
+	}
+	return nil
+}
+
+// FiscalRange returns the first and last day of the fiscal month of the passed month and year.
+// The incomes are only used by the payday strategy and have to cover the previous, the passed
+// and the next calendar month. The calendar defines the business days fixed days are shifted to.
+func FiscalRange(settings FiscalSettings, month, year int, incomes []transaction.Transaction, cal *calendar.Calendar) (time.Time, time.Time) {
+	next := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+
+	start := FiscalStartDate(settings, month, year, incomes, cal)
+	end := FiscalStartDate(settings, int(next.Month()), next.Year(), incomes, cal)
+
+	// the fiscal month ends the day before the next one starts
+	return start, end.AddDate(0, 0, -1)
+}
+
+// FiscalStartDate returns the first day of the fiscal month of the passed month and year.
+// With the payday strategy, months without any income start with the calendar month.
+func FiscalStartDate(settings FiscalSettings, month, year int, incomes []transaction.Transaction, cal *calendar.Calendar) time.Time {
+	startOfMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	endOfMonth := startOfMonth.AddDate(0, 1, -1)
+	earliest := startOfMonth.AddDate(0, 0, -settings.ToleranceDays+1)
+
+	switch settings.Strategy {
+	case FiscalStrategyFixedDay:
+		start := dayOfMonth(startOfMonth, settings.Day)
+		if fixedDayInPreviousMonth(settings) {
+			start = dayOfMonth(startOfMonth.AddDate(0, -1, 0), settings.Day)
+		}
+		return shift(cal, start, settings.Shift)
+	case FiscalStrategyPayday:
+		// the earliest income within the tolerance starts the fiscal month, independent of the order of the incomes
+		var (
+			start time.Time
+			found bool
+		)
+		for _, t := range incomes {
+			if t.BookingDate.Before(earliest) || t.BookingDate.After(endOfMonth) {
+				continue
+			}
+			if !found || t.BookingDate.Before(start) {
+				start = t.BookingDate
+				found = true
+			}
+		}
+		if found {
+			return start
+		}
+	}
+
+	return startOfMonth
+}
+
+// fixedDayInPreviousMonth returns true, if fiscal months with a fixed day start in the previous
+// calendar month. The day is compared with the tolerance of the longest month, so every fiscal
+// month starts in the same relative month and consecutive fiscal months never share their start.
+func fixedDayInPreviousMonth(settings FiscalSettings) bool {
+	return settings.Day >= 33-settings.ToleranceDays
+}
+
+// fiscalMonths returns the number of fiscal months of a fiscal month, quarter or year.
+func fiscalMonths(granularity Granularity) (int, error) {
+	switch granularity {
+	case GranularityFiscalMonth:
+		return 1, nil
+	case GranularityFiscalQuarter:
+		return 3, nil
+	case GranularityFiscalYear:
+		return 12, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownGranularity, granularity)
+}
+
+// alignFiscal returns the first month of the fiscal month, quarter or year containing the month.
+func alignFiscal(month time.Time, months, yearStartMonth int) time.Time {
+	offset := ((int(month.Month())-yearStartMonth)%months + months) % months
+	return time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -offset, 0)
+}
+
+// fiscalKey returns the key of the fiscal period starting with the month. Fiscal months are named
+// like calendar months, fiscal years by the calendar year they start in, e.g. FY2024 or FY2024-Q3.
+func fiscalKey(granularity Granularity, month time.Time, yearStartMonth int) string {
+	fiscalYear := month.Year()
+	if int(month.Month()) < yearStartMonth {
+		fiscalYear--
+	}
+
+	switch granularity {
+	case GranularityFiscalQuarter:
+		quarter := ((int(month.Month())-yearStartMonth+12)%12)/3 + 1
+		return fmt.Sprintf("FY%d-Q%d", fiscalYear, quarter)
+	case GranularityFiscalYear:
+		return fmt.Sprintf("FY%d", fiscalYear)
+	}
+	return periodKey(GranularityMonth, month)
+}
+
+// dayOfMonth returns the passed day of the month of the date, clamped to the last day of the month.
+func dayOfMonth(date time.Time, day int) time.Time {
+	lastDay := time.Date(date.Year(), date.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(date.Year(), date.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// shift moves a date falling on a weekend or holiday to the previous or next business day.
+func shift(cal *calendar.Calendar, date time.Time, shift Shift) time.Time {
+	switch shift {
+	case ShiftPrevious:
+		return cal.PreviousBusinessDay(date)
+	case ShiftNext:
+		return cal.NextBusinessDay(date)
+	}
+	return date
+}
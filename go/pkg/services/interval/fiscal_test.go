@@ -0,0 +1,265 @@
+package interval
+
+import (
+	"testing"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FiscalRange(t *testing.T) {
+	incomes := []transaction.Transaction{
+		{BookingDate: time.Date(2024, 3, 28, 0, 0, 0, 0, time.UTC)},
+		{BookingDate: time.Date(2024, 2, 27, 0, 0, 0, 0, time.UTC)},
+		{BookingDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{BookingDate: time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name      string
+		settings  FiscalSettings
+		month     int
+		year      int
+		incomes   []transaction.Transaction
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "should use the calendar month",
+			settings:  FiscalSettings{Strategy: FiscalStrategyCalendar, ToleranceDays: 15},
+			month:     2,
+			year:      2024,
+			incomes:   incomes,
+			wantStart: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should start on a fixed day of the previous month",
+			settings:  FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 25, Shift: ShiftNone, ToleranceDays: 15},
+			month:     3,
+			year:      2024,
+			wantStart: time.Date(2024, 2, 25, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should start on a fixed day of the same month outside of the tolerance",
+			settings:  FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 10, Shift: ShiftNone, ToleranceDays: 15},
+			month:     3,
+			year:      2024,
+			wantStart: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 4, 9, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should shift a fixed day on a weekend to the previous business day",
+			settings:  FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 25, Shift: ShiftPrevious, ToleranceDays: 15},
+			month:     3,
+			year:      2024,
+			wantStart: time.Date(2024, 2, 23, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should shift a fixed day on a weekend to the next business day",
+			settings:  FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 25, Shift: ShiftNext, ToleranceDays: 15},
+			month:     3,
+			year:      2024,
+			wantStart: time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should shift a fixed day on a holiday to the previous business day",
+			settings:  FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 25, Shift: ShiftPrevious, ToleranceDays: 15},
+			month:     1,
+			year:      2025,
+			wantStart: time.Date(2024, 12, 24, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 1, 23, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should clamp a fixed day to the end of the month",
+			settings:  FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 31, Shift: ShiftNone, ToleranceDays: 15},
+			month:     3,
+			year:      2024,
+			wantStart: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should use the earliest income independent of the order",
+			settings:  FiscalSettings{Strategy: FiscalStrategyPayday, ToleranceDays: 15},
+			month:     3,
+			year:      2024,
+			incomes:   incomes,
+			wantStart: time.Date(2024, 2, 27, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 27, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "should start with the calendar month without income",
+			settings: FiscalSettings{Strategy: FiscalStrategyPayday, ToleranceDays: 15},
+			month:    4,
+			year:     2024,
+			incomes: []transaction.Transaction{
+				{BookingDate: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)},
+			},
+			wantStart: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "should ignore incomes outside of the tolerance",
+			settings:  FiscalSettings{Strategy: FiscalStrategyPayday, ToleranceDays: 3},
+			month:     3,
+			year:      2024,
+			incomes:   incomes,
+			wantStart: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := FiscalRange(tt.settings, tt.month, tt.year, tt.incomes, calendar.New(calendar.StateNone))
+			assert.Equal(t, tt.wantStart, start)
+			assert.Equal(t, tt.wantEnd, end)
+		})
+	}
+}
+
+func Test_FiscalRange_fixedDay(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	// February follows a month with 31 days, March one with 29 days and May one with 30 days
+	tests := []struct {
+		day       int
+		month     int
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{day: 16, month: 2, wantStart: date(2, 16), wantEnd: date(3, 15)},
+		{day: 16, month: 3, wantStart: date(3, 16), wantEnd: date(4, 15)},
+		{day: 16, month: 5, wantStart: date(5, 16), wantEnd: date(6, 15)},
+		{day: 17, month: 2, wantStart: date(2, 17), wantEnd: date(3, 16)},
+		{day: 17, month: 3, wantStart: date(3, 17), wantEnd: date(4, 16)},
+		{day: 17, month: 5, wantStart: date(5, 17), wantEnd: date(6, 16)},
+		{day: 18, month: 2, wantStart: date(1, 18), wantEnd: date(2, 17)},
+		{day: 18, month: 3, wantStart: date(2, 18), wantEnd: date(3, 17)},
+		{day: 18, month: 5, wantStart: date(4, 18), wantEnd: date(5, 17)},
+		{day: 19, month: 2, wantStart: date(1, 19), wantEnd: date(2, 18)},
+		{day: 19, month: 3, wantStart: date(2, 19), wantEnd: date(3, 18)},
+		{day: 19, month: 5, wantStart: date(4, 19), wantEnd: date(5, 18)},
+		{day: 20, month: 2, wantStart: date(1, 20), wantEnd: date(2, 19)},
+		{day: 20, month: 3, wantStart: date(2, 20), wantEnd: date(3, 19)},
+		{day: 20, month: 5, wantStart: date(4, 20), wantEnd: date(5, 19)},
+	}
+
+	cal := calendar.New(calendar.StateNone)
+	for _, tt := range tests {
+		settings := FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: tt.day, Shift: ShiftNone, ToleranceDays: 15}
+		start, end := FiscalRange(settings, tt.month, 2024, nil, cal)
+		assert.Equal(t, tt.wantStart, start, "start of month %d with day %d", tt.month, tt.day)
+		assert.Equal(t, tt.wantEnd, end, "end of month %d with day %d", tt.month, tt.day)
+	}
+
+	// consecutive fiscal months never overlap or leave gaps
+	for day := 1; day <= 31; day++ {
+		settings := FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: day, Shift: ShiftNone, ToleranceDays: 15}
+		_, previousEnd := FiscalRange(settings, 12, 2023, nil, cal)
+		for month := 1; month <= 12; month++ {
+			start, end := FiscalRange(settings, month, 2024, nil, cal)
+			assert.False(t, end.Before(start), "month %d with day %d is inverted", month, day)
+			assert.Equal(t, previousEnd.AddDate(0, 0, 1), start, "month %d with day %d", month, day)
+			previousEnd = end
+		}
+	}
+}
+
+func Test_FiscalSettings_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings FiscalSettings
+		wantErr  bool
+	}{
+		{
+			name:     "should accept the default settings",
+			settings: DefaultFiscalSettings(),
+		},
+		{
+			name:     "should reject unknown strategies",
+			settings: FiscalSettings{Strategy: "weekly", Shift: ShiftNone, ToleranceDays: 15},
+			wantErr:  true,
+		},
+		{
+			name:     "should reject fixed days outside of a month",
+			settings: FiscalSettings{Strategy: FiscalStrategyFixedDay, Day: 32, Shift: ShiftNone, ToleranceDays: 15},
+			wantErr:  true,
+		},
+		{
+			name:     "should reject unknown shifts",
+			settings: FiscalSettings{Strategy: FiscalStrategyCalendar, Shift: "nearest", ToleranceDays: 15},
+			wantErr:  true,
+		},
+		{
+			name:     "should reject a tolerance longer than a month",
+			settings: FiscalSettings{Strategy: FiscalStrategyPayday, Shift: ShiftNone, ToleranceDays: 40},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.settings.Validate()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidFiscalSettings)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func Test_fiscalKey(t *testing.T) {
+	tests := []struct {
+		name           string
+		granularity    Granularity
+		month          time.Time
+		yearStartMonth int
+		wantAligned    time.Time
+		wantKey        string
+	}{
+		{
+			name:           "should name fiscal years after the calendar year they start in",
+			granularity:    GranularityFiscalYear,
+			month:          time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			yearStartMonth: 4,
+			wantAligned:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+			wantKey:        "FY2024",
+		},
+		{
+			name:           "should align fiscal quarters to the start of the fiscal year",
+			granularity:    GranularityFiscalQuarter,
+			month:          time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			yearStartMonth: 4,
+			wantAligned:    time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			wantKey:        "FY2024-Q2",
+		},
+		{
+			name:           "should name fiscal months like calendar months",
+			granularity:    GranularityFiscalMonth,
+			month:          time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			yearStartMonth: 4,
+			wantAligned:    time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC),
+			wantKey:        "2024-09",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			months, err := fiscalMonths(tt.granularity)
+			assert.NoError(t, err)
+
+			aligned := alignFiscal(tt.month, months, tt.yearStartMonth)
+			assert.Equal(t, tt.wantAligned, aligned)
+			assert.Equal(t, tt.wantKey, fiscalKey(tt.granularity, aligned, tt.yearStartMonth))
+		})
+	}
+}
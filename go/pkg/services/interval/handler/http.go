@@ -2,10 +2,13 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/lock"
 	"github.com/gin-gonic/gin"
 )
 
@@ -20,6 +23,17 @@ func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
 
 	intervalAPI := router.Group("/interval")
 	intervalAPI.GET("/fiscal-month", handler.GetFiscalMonth)
+	intervalAPI.PUT("/fiscal-month", handler.OverrideFiscalMonth)
+	intervalAPI.DELETE("/fiscal-month", handler.ResetFiscalMonth)
+	intervalAPI.GET("/fiscal-months", handler.ListFiscalMonths)
+	intervalAPI.POST("/fiscal-months", handler.StoreFiscalMonths)
+	intervalAPI.GET("/period", handler.ResolvePeriod)
+	intervalAPI.GET("/periods", handler.ListPeriods)
+	intervalAPI.GET("/custom-periods", handler.ListCustomPeriods)
+	intervalAPI.POST("/custom-periods", handler.CreateCustomPeriod)
+	intervalAPI.DELETE("/custom-periods/:id", handler.DeleteCustomPeriod)
+	intervalAPI.GET("/fiscal-settings", handler.GetFiscalSettings)
+	intervalAPI.PUT("/fiscal-settings", handler.SetFiscalSettings)
 
 	return handler
 }
@@ -37,10 +51,14 @@ func (h *Handler) GetFiscalMonth(c *gin.Context) {
 		return
 	}
 
-	incomeCategoryID, err := strconv.ParseInt(c.Query("income_category_id"), 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+	// the income category overrides the configured fiscal settings
+	var incomeCategoryID int64
+	if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+		incomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
 	start, end, err := h.Service.GetFiscalMonthWithIncomeCategoryID(month, year, incomeCategoryID)
@@ -56,3 +74,226 @@ func (h *Handler) GetFiscalMonth(c *gin.Context) {
 		End:   *end,
 	})
 }
+
+func (h *Handler) GetFiscalSettings(c *gin.Context) {
+	settings, err := h.Service.FiscalSettings()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+func (h *Handler) SetFiscalSettings(c *gin.Context) {
+	var settings interval.FiscalSettings
+	err := c.BindJSON(&settings)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Service.SetFiscalSettings(settings)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ListFiscalMonths returns the fiscal months of the passed year or of the months of the period
+// or from from to to.
+func (h *Handler) ListFiscalMonths(c *gin.Context) {
+	from, to, err := h.fiscalMonthRange(c)
+	if err != nil {
+		return
+	}
+
+	months, err := h.Service.FiscalMonths(from, to)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, months)
+}
+
+// StoreFiscalMonths stores the fiscal months selected like for ListFiscalMonths, so they
+// aren't calculated again, if the fiscal settings or the incomes change afterwards.
+func (h *Handler) StoreFiscalMonths(c *gin.Context) {
+	from, to, err := h.fiscalMonthRange(c)
+	if err != nil {
+		return
+	}
+
+	months, err := h.Service.StoreFiscalMonths(from, to)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, months)
+}
+
+// fiscalMonthRange returns the range of the passed year, period or from and to. Invalid
+// parameters are responded to already.
+func (h *Handler) fiscalMonthRange(c *gin.Context) (time.Time, time.Time, error) {
+	rawYear := c.Query("year")
+	if rawYear == "" {
+		from, to, err := h.Service.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			handleError(c, err)
+		}
+		return from, to, err
+	}
+
+	year, err := strconv.Atoi(rawYear)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return time.Time{}, time.Time{}, err
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC)
+	return from, to, nil
+}
+
+func (h *Handler) OverrideFiscalMonth(c *gin.Context) {
+	var overrideRequest interval.FiscalMonthOverrideRequest
+	err := c.BindJSON(&overrideRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fiscalMonth, err := h.Service.OverrideFiscalMonth(overrideRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, fiscalMonth)
+}
+
+func (h *Handler) ResetFiscalMonth(c *gin.Context) {
+	year, err := strconv.Atoi(c.Query("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	month, err := strconv.Atoi(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.ResetFiscalMonth(month, year)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResolvePeriod returns the boundaries of the period selected with the period parameter, e.g. fiscal_year:FY2024.
+func (h *Handler) ResolvePeriod(c *gin.Context) {
+	selector, err := interval.ParsePeriodSelector(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	period, err := h.Service.ResolvePeriod(*selector)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, period)
+}
+
+// ListPeriods returns the periods of the granularity within the period or between from and to.
+func (h *Handler) ListPeriods(c *gin.Context) {
+	granularity, err := interval.ParseGranularity(c.Query("granularity"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	from, to, err := h.Service.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	periods, err := h.Service.Periods(granularity, from, to, 0)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, periods)
+}
+
+func (h *Handler) ListCustomPeriods(c *gin.Context) {
+	customPeriods, err := h.Service.ListCustomPeriods()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, customPeriods)
+}
+
+func (h *Handler) CreateCustomPeriod(c *gin.Context) {
+	var createRequest interval.CustomPeriodCreateRequest
+	err := c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	customPeriod, err := h.Service.CreateCustomPeriod(createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, customPeriod)
+}
+
+func (h *Handler) DeleteCustomPeriod(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteCustomPeriod(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, interval.ErrCustomPeriodNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, interval.ErrInvalidFiscalSettings),
+		errors.Is(err, interval.ErrInvalidFiscalMonth),
+		errors.Is(err, interval.ErrInvalidPeriod),
+		errors.Is(err, interval.ErrInvalidCustomPeriod),
+		errors.Is(err, interval.ErrUnknownGranularity),
+		errors.Is(err, interval.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, lock.ErrPeriodLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
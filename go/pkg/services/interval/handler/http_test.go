@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Handler_InvalidPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// invalid ranges are rejected before the database is used
+	handler := &Handler{Service: interval.NewService(nil)}
+
+	tests := []struct {
+		name   string
+		handle gin.HandlerFunc
+		query  string
+	}{
+		{name: "should reject an invalid period of fiscal months", handle: handler.ListFiscalMonths, query: "?period=someday"},
+		{name: "should reject an invalid date of fiscal months", handle: handler.ListFiscalMonths, query: "?from=2024-01-01&to=soon"},
+		{name: "should reject an invalid year of fiscal months", handle: handler.ListFiscalMonths, query: "?year=next"},
+		{name: "should reject an invalid period of stored fiscal months", handle: handler.StoreFiscalMonths, query: "?period=someday"},
+		{name: "should reject an invalid year of stored fiscal months", handle: handler.StoreFiscalMonths, query: "?year=next"},
+		{name: "should reject an invalid period of periods", handle: handler.ListPeriods, query: "?granularity=month&period=someday"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/interval"+tt.query, nil)
+
+			tt.handle(c)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
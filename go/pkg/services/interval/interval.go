@@ -1,10 +1,137 @@
 package interval
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownGranularity = errors.New("unknown granularity")
+	ErrInvalidRange       = errors.New("from must not be after to")
+	ErrInvalidFiscalMonth = errors.New("invalid fiscal month")
+)
 
 type FiscalMonth struct {
 	Month int       `json:"month"`
 	Year  int       `json:"year"`
 	Start time.Time `json:"start"`
 	End   time.Time `json:"end"`
+	// Overridden is set, if the start date has been set manually.
+	Overridden bool `json:"overridden"`
+}
+
+type FiscalMonthOverrideRequest struct {
+	Month int    `json:"month"`
+	Year  int    `json:"year"`
+	Start string `json:"start"`
+}
+
+type Granularity string
+
+const (
+	GranularityFiscalMonth   Granularity = "fiscal_month"
+	GranularityFiscalQuarter Granularity = "fiscal_quarter"
+	GranularityFiscalYear    Granularity = "fiscal_year"
+	// GranularityWeek are ISO weeks starting on Monday.
+	GranularityWeek    Granularity = "week"
+	GranularityMonth   Granularity = "month"
+	GranularityQuarter Granularity = "quarter"
+	GranularityYear    Granularity = "year"
+)
+
+func ParseGranularity(value string) (Granularity, error) {
+	switch granularity := Granularity(value); granularity {
+	case GranularityFiscalMonth, GranularityFiscalQuarter, GranularityFiscalYear,
+		GranularityWeek, GranularityMonth, GranularityQuarter, GranularityYear:
+		return granularity, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownGranularity, value)
+}
+
+// Period is a named date range, both boundaries are inclusive.
+type Period struct {
+	Key   string    `json:"key"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Contains checks whether the passed date is within the period.
+func (p Period) Contains(date time.Time) bool {
+	return !date.Before(p.Start) && !date.After(p.End)
+}
+
+// CalendarPeriods returns the ISO weeks or calendar months, quarters or years overlapping the range
+// from from to to. The first and last period are clamped to the range, so no period exceeds it.
+func CalendarPeriods(granularity Granularity, from, to time.Time) ([]Period, error) {
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+
+	if granularity == GranularityWeek {
+		periods := make([]Period, 0)
+		for start := weekStart(from); !start.After(to); start = start.AddDate(0, 0, 7) {
+			periods = append(periods, clamp(Period{
+				Key:   periodKey(granularity, start),
+				Start: start,
+				End:   start.AddDate(0, 0, 6),
+			}, from, to))
+		}
+		return periods, nil
+	}
+
+	var months int
+	switch granularity {
+	case GranularityMonth:
+		months = 1
+	case GranularityQuarter:
+		months = 3
+	case GranularityYear:
+		months = 12
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGranularity, granularity)
+	}
+
+	// align the first period to the start of its month, quarter or year
+	startMonth := (int(from.Month())-1)/months*months + 1
+	start := time.Date(from.Year(), time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
+
+	periods := make([]Period, 0)
+	for ; !start.After(to); start = start.AddDate(0, months, 0) {
+		periods = append(periods, clamp(Period{
+			Key:   periodKey(granularity, start),
+			Start: start,
+			End:   start.AddDate(0, months, -1),
+		}, from, to))
+	}
+	return periods, nil
+}
+
+func periodKey(granularity Granularity, start time.Time) string {
+	switch granularity {
+	case GranularityWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GranularityQuarter:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case GranularityYear:
+		return fmt.Sprintf("%d", start.Year())
+	}
+	return fmt.Sprintf("%d-%02d", start.Year(), int(start.Month()))
+}
+
+func clamp(period Period, from, to time.Time) Period {
+	if period.Start.Before(from) {
+		period.Start = from
+	}
+	if period.End.After(to) {
+		period.End = to
+	}
+	return period
+}
+
+// weekStart returns the Monday of the ISO week of the date.
+func weekStart(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return time.Date(date.Year(), date.Month(), date.Day()-offset, 0, 0, 0, 0, time.UTC)
 }
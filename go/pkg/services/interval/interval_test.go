@@ -0,0 +1,94 @@
+package interval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CalendarPeriods(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		granularity Granularity
+		from        time.Time
+		to          time.Time
+		want        []Period
+		wantErr     error
+	}{
+		{
+			name:        "should clamp months to the range",
+			granularity: GranularityMonth,
+			from:        date(2023, 12, 15),
+			to:          date(2024, 2, 10),
+			want: []Period{
+				{Key: "2023-12", Start: date(2023, 12, 15), End: date(2023, 12, 31)},
+				{Key: "2024-01", Start: date(2024, 1, 1), End: date(2024, 1, 31)},
+				{Key: "2024-02", Start: date(2024, 2, 1), End: date(2024, 2, 10)},
+			},
+		},
+		{
+			name:        "should align quarters",
+			granularity: GranularityQuarter,
+			from:        date(2024, 2, 1),
+			to:          date(2024, 9, 30),
+			want: []Period{
+				{Key: "2024-Q1", Start: date(2024, 2, 1), End: date(2024, 3, 31)},
+				{Key: "2024-Q2", Start: date(2024, 4, 1), End: date(2024, 6, 30)},
+				{Key: "2024-Q3", Start: date(2024, 7, 1), End: date(2024, 9, 30)},
+			},
+		},
+		{
+			name:        "should return years",
+			granularity: GranularityYear,
+			from:        date(2023, 1, 1),
+			to:          date(2024, 12, 31),
+			want: []Period{
+				{Key: "2023", Start: date(2023, 1, 1), End: date(2023, 12, 31)},
+				{Key: "2024", Start: date(2024, 1, 1), End: date(2024, 12, 31)},
+			},
+		},
+		{
+			name:        "should align ISO weeks to monday",
+			granularity: GranularityWeek,
+			from:        date(2024, 1, 3),
+			to:          date(2024, 1, 15),
+			want: []Period{
+				{Key: "2024-W01", Start: date(2024, 1, 3), End: date(2024, 1, 7)},
+				{Key: "2024-W02", Start: date(2024, 1, 8), End: date(2024, 1, 14)},
+				{Key: "2024-W03", Start: date(2024, 1, 15), End: date(2024, 1, 15)},
+			},
+		},
+		{
+			name:        "should reject inverted ranges",
+			granularity: GranularityMonth,
+			from:        date(2024, 2, 1),
+			to:          date(2024, 1, 1),
+			wantErr:     ErrInvalidRange,
+		},
+		{
+			name:        "should reject fiscal months",
+			granularity: GranularityFiscalMonth,
+			from:        date(2024, 1, 1),
+			to:          date(2024, 2, 1),
+			wantErr:     ErrUnknownGranularity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalendarPeriods(tt.granularity, tt.from, tt.to)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package interval
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidPeriod        = errors.New("invalid period")
+	ErrInvalidCustomPeriod  = errors.New("invalid custom period")
+	ErrCustomPeriodNotFound = errors.New("custom period not found")
+)
+
+// selectorCustom is the prefix of selectors of custom periods.
+const selectorCustom = "custom"
+
+// PeriodSelector selects a single period by its granularity and key, e.g. month:2024-05,
+// week:2024-W05, fiscal_year:FY2024 or custom:3 for the custom period with the ID 3.
+type PeriodSelector struct {
+	Granularity    Granularity
+	Key            string
+	CustomPeriodID int64
+}
+
+// CustomPeriod is a named period, e.g. a vacation.
+type CustomPeriod struct {
+	ID    int64     `json:"id"`
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type CustomPeriodCreateRequest struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func ParsePeriodSelector(value string) (*PeriodSelector, error) {
+	rawGranularity, key, ok := strings.Cut(value, ":")
+	if !ok || key == "" {
+		return nil, fmt.Errorf("%w: %s must be <granularity>:<key>", ErrInvalidPeriod, value)
+	}
+
+	if rawGranularity == selectorCustom {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPeriod, err)
+		}
+		return &PeriodSelector{CustomPeriodID: id}, nil
+	}
+
+	granularity, err := ParseGranularity(rawGranularity)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPeriod, err)
+	}
+	return &PeriodSelector{Granularity: granularity, Key: key}, nil
+}
+
+// CalendarPeriod returns the ISO week or calendar month, quarter or year with the passed key.
+func CalendarPeriod(granularity Granularity, key string) (*Period, error) {
+	var start, end time.Time
+	switch granularity {
+	case GranularityWeek:
+		var year, week int
+		_, err := fmt.Sscanf(key, "%d-W%d", &year, &week)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPeriod, key)
+		}
+		// the 4th of January is always part of the first ISO week
+		start = weekStart(time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)).AddDate(0, 0, (week-1)*7)
+		end = start.AddDate(0, 0, 6)
+	case GranularityMonth:
+		var err error
+		start, err = time.Parse("2006-01", key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPeriod, key)
+		}
+		end = start.AddDate(0, 1, -1)
+	case GranularityQuarter:
+		var year, quarter int
+		_, err := fmt.Sscanf(key, "%d-Q%d", &year, &quarter)
+		if err != nil || quarter < 1 || quarter > 4 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPeriod, key)
+		}
+		start = time.Date(year, time.Month((quarter-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 3, -1)
+	case GranularityYear:
+		year, err := strconv.Atoi(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidPeriod, key)
+		}
+		start = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(1, 0, -1)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGranularity, granularity)
+	}
+
+	// reject keys like 2024-W60, which would silently select a period of the next year
+	if periodKey(granularity, start) != key {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPeriod, key)
+	}
+	return &Period{Key: key, Start: start, End: end}, nil
+}
+
+// fiscalFirstMonth returns the first calendar month of the fiscal month, quarter or year with the passed key.
+func fiscalFirstMonth(granularity Granularity, key string, yearStartMonth int) (time.Time, error) {
+	var (
+		month time.Time
+		err   error
+	)
+	switch granularity {
+	case GranularityFiscalMonth:
+		month, err = time.Parse("2006-01", key)
+	case GranularityFiscalQuarter:
+		var year, quarter int
+		_, err = fmt.Sscanf(key, "FY%d-Q%d", &year, &quarter)
+		month = time.Date(year, time.Month(yearStartMonth), 1, 0, 0, 0, 0, time.UTC).AddDate(0, (quarter-1)*3, 0)
+	case GranularityFiscalYear:
+		var year int
+		_, err = fmt.Sscanf(key, "FY%d", &year)
+		month = time.Date(year, time.Month(yearStartMonth), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Time{}, fmt.Errorf("%w: %s", ErrUnknownGranularity, granularity)
+	}
+
+	if err != nil || fiscalKey(granularity, month, yearStartMonth) != key {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrInvalidPeriod, key)
+	}
+	return month, nil
+}
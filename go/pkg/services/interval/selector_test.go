@@ -0,0 +1,183 @@
+package interval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParsePeriodSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    *PeriodSelector
+		wantErr error
+	}{
+		{
+			name:  "should parse calendar periods",
+			value: "week:2024-W05",
+			want:  &PeriodSelector{Granularity: GranularityWeek, Key: "2024-W05"},
+		},
+		{
+			name:  "should parse fiscal periods",
+			value: "fiscal_quarter:FY2024-Q3",
+			want:  &PeriodSelector{Granularity: GranularityFiscalQuarter, Key: "FY2024-Q3"},
+		},
+		{
+			name:  "should parse custom periods",
+			value: "custom:3",
+			want:  &PeriodSelector{CustomPeriodID: 3},
+		},
+		{
+			name:    "should reject selectors without key",
+			value:   "month",
+			wantErr: ErrInvalidPeriod,
+		},
+		{
+			name:    "should reject unknown granularities",
+			value:   "decade:2020",
+			wantErr: ErrInvalidPeriod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePeriodSelector(tt.value)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_CalendarPeriod(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name        string
+		granularity Granularity
+		key         string
+		wantStart   time.Time
+		wantEnd     time.Time
+		wantErr     bool
+	}{
+		{
+			name:        "should return the first ISO week starting in the previous year",
+			granularity: GranularityWeek,
+			key:         "2025-W01",
+			wantStart:   date(2024, 12, 30),
+			wantEnd:     date(2025, 1, 5),
+		},
+		{
+			name:        "should return the 53rd ISO week",
+			granularity: GranularityWeek,
+			key:         "2020-W53",
+			wantStart:   date(2020, 12, 28),
+			wantEnd:     date(2021, 1, 3),
+		},
+		{
+			name:        "should reject weeks not existing in the year",
+			granularity: GranularityWeek,
+			key:         "2024-W53",
+			wantErr:     true,
+		},
+		{
+			name:        "should return months",
+			granularity: GranularityMonth,
+			key:         "2024-02",
+			wantStart:   date(2024, 2, 1),
+			wantEnd:     date(2024, 2, 29),
+		},
+		{
+			name:        "should return quarters",
+			granularity: GranularityQuarter,
+			key:         "2024-Q4",
+			wantStart:   date(2024, 10, 1),
+			wantEnd:     date(2024, 12, 31),
+		},
+		{
+			name:        "should reject invalid quarters",
+			granularity: GranularityQuarter,
+			key:         "2024-Q5",
+			wantErr:     true,
+		},
+		{
+			name:        "should return years",
+			granularity: GranularityYear,
+			key:         "2024",
+			wantStart:   date(2024, 1, 1),
+			wantEnd:     date(2024, 12, 31),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CalendarPeriod(tt.granularity, tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPeriod)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStart, got.Start)
+			assert.Equal(t, tt.wantEnd, got.End)
+		})
+	}
+}
+
+func Test_fiscalFirstMonth(t *testing.T) {
+	tests := []struct {
+		name           string
+		granularity    Granularity
+		key            string
+		yearStartMonth int
+		want           time.Time
+		wantErr        bool
+	}{
+		{
+			name:           "should return the first month of a fiscal year",
+			granularity:    GranularityFiscalYear,
+			key:            "FY2024",
+			yearStartMonth: 4,
+			want:           time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "should return the first month of a fiscal quarter in the next calendar year",
+			granularity:    GranularityFiscalQuarter,
+			key:            "FY2024-Q4",
+			yearStartMonth: 4,
+			want:           time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:           "should reject invalid quarters",
+			granularity:    GranularityFiscalQuarter,
+			key:            "FY2024-Q5",
+			yearStartMonth: 4,
+			wantErr:        true,
+		},
+		{
+			name:           "should return fiscal months",
+			granularity:    GranularityFiscalMonth,
+			key:            "2024-05",
+			yearStartMonth: 4,
+			want:           time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fiscalFirstMonth(tt.granularity, tt.key, tt.yearStartMonth)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPeriod)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
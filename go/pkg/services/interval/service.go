@@ -2,72 +2,697 @@ package interval
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"time"
 
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/lock"
 	"docqube.de/bookkeeper/pkg/services/transaction"
 	"docqube.de/bookkeeper/pkg/utils"
 )
 
+// fiscalSettingsKey is the key the fiscal settings of the household are stored with.
+const fiscalSettingsKey = "fiscal_month"
+
 type Service struct {
+	db                 *sql.DB
+	calendarService    *calendar.Service
 	transactionService *transaction.Service
+	lockService        *lock.Service
 }
 
 func NewService(db *sql.DB) *Service {
 	return &Service{
+		db:                 db,
+		calendarService:    calendar.NewService(db),
 		transactionService: transaction.NewService(db),
+		lockService:        lock.NewService(db),
 	}
 }
 
-func (s *Service) GetFiscalMonthWithIncomeCategoryID(month int, year int, incomeCategoryID int64) (*time.Time, *time.Time, error) {
-	previousMonthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
-	previousMonthStart.AddDate(0, -1, 0)
-	nextMonthEnd := previousMonthStart.AddDate(0, 3, -1)
+// FiscalSettings returns the fiscal settings of the household or the default settings, if none are stored yet.
+func (s *Service) FiscalSettings() (*FiscalSettings, error) {
+	settings := DefaultFiscalSettings()
+
+	var value []byte
+	err := s.db.QueryRow(`
+		SELECT value
+		FROM settings
+		WHERE key = $1;
+	`, fiscalSettingsKey).Scan(&value)
+	if err == sql.ErrNoRows {
+		return &settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(value, &settings)
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (s *Service) SetFiscalSettings(settings FiscalSettings) (*FiscalSettings, error) {
+	if settings.Shift == "" {
+		settings.Shift = ShiftNone
+	}
+	if settings.ToleranceDays == 0 {
+		settings.ToleranceDays = defaultToleranceDays
+	}
+	if settings.IncomeCategoryIDs == nil {
+		settings.IncomeCategoryIDs = []int64{}
+	}
+	if settings.YearStartMonth == 0 {
+		settings.YearStartMonth = 1
+	}
+	err := settings.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	// the fiscal months of locked periods keep the start dates of the previous settings
+	locks, err := s.lockService.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range locks {
+		_, err = s.StoreFiscalMonths(l.Start, l.End.AddDate(0, 1, 0))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO settings (key, value)
+		VALUES ($1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET value = EXCLUDED.value;
+	`, fiscalSettingsKey, value)
+	if err != nil {
+		return nil, err
+	}
+
+	// the stored fiscal months are calculated again with the new settings, unless they are overridden or locked
+	_, err = tx.Exec(`
+		DELETE FROM fiscal_months AS f
+		WHERE
+			NOT f.overridden
+		AND
+			NOT EXISTS (
+				SELECT 1
+				FROM period_locks AS l
+				WHERE f.start_date BETWEEN l.start_date AND l.end_date
+			);
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// resolveFiscalSettings returns the fiscal settings of the household. A passed income
+// category replaces the configured income categories, unless it is zero.
+func (s *Service) resolveFiscalSettings(incomeCategoryID int64) (*FiscalSettings, error) {
+	settings, err := s.FiscalSettings()
+	if err != nil {
+		return nil, err
+	}
+	if incomeCategoryID != 0 {
+		settings.IncomeCategoryIDs = []int64{incomeCategoryID}
+	}
+	return settings, nil
+}
+
+// fiscalRange returns the first and last day of the fiscal month using the passed settings.
+// Persisted fiscal months are only used with the configured income categories.
+func (s *Service) fiscalRange(settings FiscalSettings, month, year int, persisted bool) (time.Time, time.Time, error) {
+	next := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+
+	start, _, err := s.fiscalStartDate(settings, month, year, persisted)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, _, err := s.fiscalStartDate(settings, int(next.Month()), next.Year(), persisted)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	// the fiscal month ends the day before the next one starts
+	return start, end.AddDate(0, 0, -1), nil
+}
+
+// fiscalStartDate returns the first day of the fiscal month and whether it has been overridden.
+// If persisted is set, the start date stored by StoreFiscalMonths or an override is returned,
+// if there is one. The calculated start date is never stored, so reads have no side effects.
+func (s *Service) fiscalStartDate(settings FiscalSettings, month, year int, persisted bool) (time.Time, bool, error) {
+	if persisted {
+		var (
+			start      time.Time
+			overridden bool
+		)
+		err := s.db.QueryRow(`
+			SELECT start_date, overridden
+			FROM fiscal_months
+			WHERE year = $1 AND month = $2;
+		`, year, month).Scan(&start, &overridden)
+		if err == nil {
+			return start, overridden, nil
+		}
+		if err != sql.ErrNoRows {
+			return time.Time{}, false, err
+		}
+	}
+
+	incomes := make([]transaction.Transaction, 0)
+	if settings.Strategy == FiscalStrategyPayday {
+		previousMonthStart := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -1, 0)
+		monthEnd := previousMonthStart.AddDate(0, 2, -1)
+
+		for _, incomeCategoryID := range settings.IncomeCategoryIDs {
+			transactions, err := s.transactionService.List(transaction.Filter{
+				From:        &previousMonthStart,
+				To:          &monthEnd,
+				CategoryIDs: []int64{incomeCategoryID},
+				Hidden:      utils.NewBool(false),
+			}, transaction.Chronological, transaction.Page{})
+			if err != nil {
+				return time.Time{}, false, err
+			}
+			incomes = append(incomes, transactions.Items...)
+		}
+	}
+	// only fixed days are shifted to business days
+	cal := calendar.New(calendar.StateNone)
+	if settings.Strategy == FiscalStrategyFixedDay && settings.Shift != ShiftNone {
+		var err error
+		cal, err = s.calendarService.Calendar()
+		if err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	return FiscalStartDate(settings, month, year, incomes, cal), false, nil
+}
+
+// FiscalMonths returns every fiscal month from the month of from to the month of to
+// using the fiscal settings of the household.
+func (s *Service) FiscalMonths(from, to time.Time) ([]FiscalMonth, error) {
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+
+	settings, err := s.FiscalSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	months := make([]FiscalMonth, 0)
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for month := first; !month.After(to); month = month.AddDate(0, 1, 0) {
+		next := month.AddDate(0, 1, 0)
+
+		start, overridden, err := s.fiscalStartDate(*settings, int(month.Month()), month.Year(), true)
+		if err != nil {
+			return nil, err
+		}
+		end, _, err := s.fiscalStartDate(*settings, int(next.Month()), next.Year(), true)
+		if err != nil {
+			return nil, err
+		}
+
+		months = append(months, FiscalMonth{
+			Month:      int(month.Month()),
+			Year:       month.Year(),
+			Start:      start,
+			End:        end.AddDate(0, 0, -1),
+			Overridden: overridden,
+		})
+	}
+	return months, nil
+}
+
+// StoreFiscalMonths stores the start dates of the fiscal months from the month of from to the month
+// of to and of the following fiscal month, which ends the last one. Stored fiscal months stay stable,
+// even if the fiscal settings or the incomes change afterwards. Already stored ones are kept.
+func (s *Service) StoreFiscalMonths(from, to time.Time) ([]FiscalMonth, error) {
+	months, err := s.FiscalMonths(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	last := months[len(months)-1]
+	next := time.Date(last.Year, time.Month(last.Month), 1, 0, 0, 0, 0, time.UTC).AddDate(0, 1, 0)
+	starts := append(months, FiscalMonth{
+		Month: int(next.Month()),
+		Year:  next.Year(),
+		Start: last.End.AddDate(0, 0, 1),
+	})
+	for _, month := range starts {
+		_, err = tx.Exec(`
+			INSERT INTO fiscal_months (year, month, start_date)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (year, month) DO NOTHING;
+		`, month.Year, month.Month, month.Start)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return months, nil
+}
+
+// OverrideFiscalMonth sets the start date of the fiscal month manually, e.g. if the salary arrived
+// early. The end of the previous fiscal month moves as well. Locked fiscal months can't be changed.
+func (s *Service) OverrideFiscalMonth(request FiscalMonthOverrideRequest) (*FiscalMonth, error) {
+	if request.Month < 1 || request.Month > 12 {
+		return nil, fmt.Errorf("%w: month must be between 1 and 12", ErrInvalidFiscalMonth)
+	}
+	start, err := time.Parse(time.DateOnly, request.Start)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFiscalMonth, err)
+	}
 
-	incomeTransactions, err := s.transactionService.ListByCategoryID(previousMonthStart, nextMonthEnd, incomeCategoryID, transaction.OrderByDirectionAsc)
+	startOfMonth := time.Date(request.Year, time.Month(request.Month), 1, 0, 0, 0, 0, time.UTC)
+	if start.Before(startOfMonth.AddDate(0, -1, 0)) || !start.Before(startOfMonth.AddDate(0, 1, 0)) {
+		return nil, fmt.Errorf("%w: start must be within the previous or the same calendar month", ErrInvalidFiscalMonth)
+	}
+
+	err = s.checkFiscalMonthUnlocked(request.Month, request.Year, start)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO fiscal_months (year, month, start_date, overridden)
+		VALUES ($1, $2, $3, true)
+		ON CONFLICT (year, month) DO UPDATE
+		SET start_date = EXCLUDED.start_date, overridden = true;
+	`, request.Year, request.Month, start)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := s.FiscalMonths(startOfMonth, startOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	return &months[0], nil
+}
+
+// ResetFiscalMonth removes the stored start date of the fiscal month, so it is calculated again.
+func (s *Service) ResetFiscalMonth(month, year int) error {
+	var start time.Time
+	err := s.db.QueryRow(`
+		SELECT start_date
+		FROM fiscal_months
+		WHERE year = $1 AND month = $2;
+	`, year, month).Scan(&start)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.checkFiscalMonthUnlocked(month, year, start)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Exec(`
+		DELETE FROM fiscal_months
+		WHERE year = $1 AND month = $2;
+	`, year, month)
+	return err
+}
+
+// InvalidateFiscalMonths removes the calculated start dates of the fiscal months affected by incomes
+// booked between from and to, so they are calculated again. Overridden and locked fiscal months are kept.
+func (s *Service) InvalidateFiscalMonths(from, to time.Time) error {
+	// incomes may start the fiscal month of the following calendar month
+	_, err := s.db.Exec(`
+		DELETE FROM fiscal_months AS f
+		WHERE
+			NOT f.overridden
+		AND
+			f.year * 12 + f.month BETWEEN $1 AND $2
+		AND
+			NOT EXISTS (
+				SELECT 1
+				FROM period_locks AS l
+				WHERE f.start_date BETWEEN l.start_date AND l.end_date
+			);
+	`, monthIndex(from), monthIndex(to)+1)
+	return err
+}
+
+// InvalidateAllFiscalMonths deletes every stored fiscal month, which is neither overridden nor locked,
+// so they are calculated again, e.g. after the holidays of the business day calendar changed.
+func (s *Service) InvalidateAllFiscalMonths() error {
+	_, err := s.db.Exec(`
+		DELETE FROM fiscal_months AS f
+		WHERE
+			NOT f.overridden
+		AND
+			NOT EXISTS (
+				SELECT 1
+				FROM period_locks AS l
+				WHERE f.start_date BETWEEN l.start_date AND l.end_date
+			);
+	`)
+	return err
+}
+
+// checkFiscalMonthUnlocked returns lock.ErrPeriodLocked, if the current or the new start date
+// of the fiscal month or the day before, which ends the previous fiscal month, is locked.
+func (s *Service) checkFiscalMonthUnlocked(month, year int, start time.Time) error {
+	locks, err := s.lockService.List()
+	if err != nil {
+		return err
+	}
+
+	current, _, err := s.GetFiscalMonthWithIncomeCategoryID(month, year, 0)
+	if err != nil {
+		return err
+	}
+
+	return lock.Check(locks, *current, current.AddDate(0, 0, -1), start, start.AddDate(0, 0, -1))
+}
+
+func monthIndex(date time.Time) int {
+	return date.Year()*12 + int(date.Month())
+}
+
+// GetFiscalMonthWithIncomeCategoryID returns the boundaries of the fiscal month using the fiscal
+// settings of the household. The income category overrides the configured ones, unless it is zero.
+func (s *Service) GetFiscalMonthWithIncomeCategoryID(month int, year int, incomeCategoryID int64) (*time.Time, *time.Time, error) {
+	settings, err := s.resolveFiscalSettings(incomeCategoryID)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	start, end := s.GetFiscalMonth(month, year, incomeTransactions.Items)
+	start, end, err := s.fiscalRange(*settings, month, year, incomeCategoryID == 0)
+	if err != nil {
+		return nil, nil, err
+	}
 	return &start, &end, nil
 }
 
-func (s *Service) GetFiscalMonth(month int, year int, incomeTransactions []transaction.Transaction) (time.Time, time.Time) {
-	nextMonth := month + 1
-	nextYear := year
-	if nextMonth > 12 {
-		nextMonth = 1
-		nextYear++
+// Periods returns the periods of the granularity overlapping the range from from to to.
+// The income category is only used to determine the boundaries of fiscal periods.
+func (s *Service) Periods(granularity Granularity, from, to time.Time, incomeCategoryID int64) ([]Period, error) {
+	months, err := fiscalMonths(granularity)
+	if err != nil {
+		return CalendarPeriods(granularity, from, to)
+	}
+	if from.After(to) {
+		return nil, ErrInvalidRange
+	}
+
+	settings, err := s.resolveFiscalSettings(incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	// fiscal periods may start in the previous calendar month or end in the next one
+	periods := make([]Period, 0)
+	month := alignFiscal(from.AddDate(0, -1, 0), months, settings.YearStartMonth)
+	for ; !month.After(to.AddDate(0, 1, 0)); month = month.AddDate(0, months, 0) {
+		period, err := s.fiscalPeriod(*settings, granularity, month, incomeCategoryID == 0)
+		if err != nil {
+			return nil, err
+		}
+		if period.End.Before(from) || period.Start.After(to) {
+			continue
+		}
+		periods = append(periods, clamp(*period, from, to))
+	}
+	return periods, nil
+}
+
+// fiscalPeriod returns the fiscal month, quarter or year starting with the fiscal month of the passed month.
+func (s *Service) fiscalPeriod(settings FiscalSettings, granularity Granularity, month time.Time, persisted bool) (*Period, error) {
+	months, err := fiscalMonths(granularity)
+	if err != nil {
+		return nil, err
 	}
+	next := month.AddDate(0, months, 0)
 
-	fiscalStart := s.CalculateFiscalStartDate(month, year, incomeTransactions)
-	fiscalEnd := s.CalculateFiscalStartDate(nextMonth, nextYear, incomeTransactions)
+	start, _, err := s.fiscalStartDate(settings, int(month.Month()), month.Year(), persisted)
+	if err != nil {
+		return nil, err
+	}
+	end, _, err := s.fiscalStartDate(settings, int(next.Month()), next.Year(), persisted)
+	if err != nil {
+		return nil, err
+	}
 
-	// return one day subtracted from the end date, as we don't want to
-	// include the next month with income in the requested fiscal month
-	return fiscalStart, fiscalEnd.AddDate(0, 0, -1)
+	return &Period{
+		Key:   fiscalKey(granularity, month, settings.YearStartMonth),
+		Start: start,
+		End:   end.AddDate(0, 0, -1),
+	}, nil
 }
 
-func (s *Service) CalculateFiscalStartDate(month int, year int, incomeTransactions []transaction.Transaction) time.Time {
-	startOfMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+// ResolvePeriod returns the period selected by the selector. Fiscal periods are determined
+// with the fiscal settings of the household.
+func (s *Service) ResolvePeriod(selector PeriodSelector) (*Period, error) {
+	if selector.CustomPeriodID != 0 {
+		customPeriod, err := s.GetCustomPeriod(selector.CustomPeriodID)
+		if err != nil {
+			return nil, err
+		}
+		return &Period{
+			Key:   customPeriod.Name,
+			Start: customPeriod.Start,
+			End:   customPeriod.End,
+		}, nil
+	}
 
-	distanceMap := make(map[int]transaction.Transaction)
-	distanceValues := make([]int, 0)
+	if _, err := fiscalMonths(selector.Granularity); err != nil {
+		return CalendarPeriod(selector.Granularity, selector.Key)
+	}
 
-	for _, t := range incomeTransactions {
-		difference := int(t.BookingDate.Sub(startOfMonth).Hours() / 24)
-		distanceMap[difference] = t
-		distanceValues = append(distanceValues, difference)
+	settings, err := s.FiscalSettings()
+	if err != nil {
+		return nil, err
 	}
+	month, err := fiscalFirstMonth(selector.Granularity, selector.Key, settings.YearStartMonth)
+	if err != nil {
+		return nil, err
+	}
+	return s.fiscalPeriod(*settings, selector.Granularity, month, true)
+}
 
-	for _, diff := range distanceValues {
-		if diff < 0 && utils.Abs(diff) >= 15 {
-			// ignore transactions that are more than 15 days
-			// before the start of the month
-			continue
+// Range returns the boundaries of the selected period, if a period selector is passed.
+// Otherwise from and to are parsed. Invalid input is reported as ErrInvalidPeriod.
+func (s *Service) Range(rawPeriod, rawFrom, rawTo string) (time.Time, time.Time, error) {
+	if rawPeriod != "" {
+		selector, err := ParsePeriodSelector(rawPeriod)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		period, err := s.ResolvePeriod(*selector)
+		if errors.Is(err, ErrCustomPeriodNotFound) {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: %w", ErrInvalidPeriod, err)
+		}
+		if err != nil {
+			return time.Time{}, time.Time{}, err
 		}
-		return distanceMap[diff].BookingDate
+		return period.Start, period.End, nil
 	}
 
-	return startOfMonth
+	from, err := time.Parse(time.DateOnly, rawFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidPeriod, err)
+	}
+	to, err := time.Parse(time.DateOnly, rawTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: %s", ErrInvalidPeriod, err)
+	}
+	return from, to, nil
+}
+
+func (s *Service) ListCustomPeriods() ([]CustomPeriod, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, start_date, end_date
+		FROM custom_periods
+		ORDER BY start_date, id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	customPeriods := make([]CustomPeriod, 0)
+	for rows.Next() {
+		var customPeriod CustomPeriod
+		err = rows.Scan(&customPeriod.ID, &customPeriod.Name, &customPeriod.Start, &customPeriod.End)
+		if err != nil {
+			return nil, err
+		}
+		customPeriods = append(customPeriods, customPeriod)
+	}
+
+	return customPeriods, rows.Err()
+}
+
+func (s *Service) GetCustomPeriod(id int64) (*CustomPeriod, error) {
+	var customPeriod CustomPeriod
+	err := s.db.QueryRow(`
+		SELECT id, name, start_date, end_date
+		FROM custom_periods
+		WHERE id = $1;
+	`, id).Scan(&customPeriod.ID, &customPeriod.Name, &customPeriod.Start, &customPeriod.End)
+	if err == sql.ErrNoRows {
+		return nil, ErrCustomPeriodNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &customPeriod, nil
+}
+
+func (s *Service) CreateCustomPeriod(request CustomPeriodCreateRequest) (*CustomPeriod, error) {
+	if request.Name == "" {
+		return nil, fmt.Errorf("%w: name missing", ErrInvalidCustomPeriod)
+	}
+	start, err := time.Parse(time.DateOnly, request.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCustomPeriod, err)
+	}
+	end, err := time.Parse(time.DateOnly, request.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCustomPeriod, err)
+	}
+	if start.After(end) {
+		return nil, fmt.Errorf("%w: from must not be after to", ErrInvalidCustomPeriod)
+	}
+
+	customPeriod := CustomPeriod{
+		Name:  request.Name,
+		Start: start,
+		End:   end,
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO custom_periods (name, start_date, end_date)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`, customPeriod.Name, start, end).Scan(&customPeriod.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &customPeriod, nil
+}
+
+func (s *Service) DeleteCustomPeriod(id int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM custom_periods
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrCustomPeriodNotFound
+	}
+	return nil
+}
+
+// MonthPeriod returns the calendar or fiscal month of the passed month and year.
+func (s *Service) MonthPeriod(granularity Granularity, month, year int, incomeCategoryID int64) (*Period, error) {
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	period := Period{
+		Key:   periodKey(GranularityMonth, start),
+		Start: start,
+		End:   start.AddDate(0, 1, -1),
+	}
+
+	switch granularity {
+	case GranularityMonth:
+		return &period, nil
+	case GranularityFiscalMonth:
+		fiscalStart, fiscalEnd, err := s.GetFiscalMonthWithIncomeCategoryID(month, year, incomeCategoryID)
+		if err != nil {
+			return nil, err
+		}
+		period.Start, period.End = *fiscalStart, *fiscalEnd
+		return &period, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownGranularity, granularity)
+}
+
+// GetFiscalMonthOfDate returns the fiscal month containing the passed date. As fiscal months
+// start with the income, the date may be part of the previous or next calendar month.
+func (s *Service) GetFiscalMonthOfDate(date time.Time, incomeCategoryID int64) (*FiscalMonth, error) {
+	settings, err := s.resolveFiscalSettings(incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	month, year := int(date.Month()), date.Year()
+	for _, offset := range []int{0, -1, 1} {
+		candidate := time.Date(year, time.Month(month)+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+
+		start, end, err := s.fiscalRange(*settings, int(candidate.Month()), candidate.Year(), incomeCategoryID == 0)
+		if err != nil {
+			return nil, err
+		}
+		if !date.Before(start) && !date.After(end) {
+			return &FiscalMonth{
+				Month: int(candidate.Month()),
+				Year:  candidate.Year(),
+				Start: start,
+				End:   end,
+			}, nil
+		}
+	}
+
+	// fall back to the calendar month, if the incomes are too irregular
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return &FiscalMonth{
+		Month: month,
+		Year:  year,
+		Start: start,
+		End:   start.AddDate(0, 1, -1),
+	}, nil
+}
+
+// GetFiscalMonth returns the boundaries of the fiscal month using the default payday strategy.
+func (s *Service) GetFiscalMonth(month int, year int, incomeTransactions []transaction.Transaction) (time.Time, time.Time) {
+	return FiscalRange(DefaultFiscalSettings(), month, year, incomeTransactions, calendar.New(calendar.StateNone))
 }
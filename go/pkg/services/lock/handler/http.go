@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/lock"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service         *lock.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         lock.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	locksAPI := router.Group("/locks")
+	locksAPI.GET("", handler.List)
+	locksAPI.POST("", handler.Create)
+
+	lockAPI := router.Group("/lock")
+	lockAPI.GET("/:id", handler.Get)
+	lockAPI.DELETE("/:id", handler.Delete)
+
+	return handler
+}
+
+func (h *Handler) List(c *gin.Context) {
+	locks, err := h.Service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, locks)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var createRequest lock.LockCreateRequest
+	err := c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var start, end time.Time
+	if createRequest.From != nil || createRequest.To != nil {
+		if createRequest.From == nil || createRequest.To == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+			return
+		}
+		start, err = time.Parse(time.DateOnly, *createRequest.From)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		end, err = time.Parse(time.DateOnly, *createRequest.To)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		var granularity interval.Granularity
+		granularity, err = interval.ParseGranularity(createRequest.Granularity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if createRequest.Month < 1 || createRequest.Month > 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "month must be between 1 and 12"})
+			return
+		}
+
+		var period *interval.Period
+		period, err = h.IntervalService.MonthPeriod(granularity, createRequest.Month, createRequest.Year, createRequest.IncomeCategoryID)
+		if err != nil {
+			if errors.Is(err, interval.ErrUnknownGranularity) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		start, end = period.Start, period.End
+	}
+
+	lock, err := h.Service.Create(start, end, createRequest.Description)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, lock)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lock, err := h.Service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lock)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.Delete(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, lock.ErrLockNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, lock.ErrInvalidLock):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
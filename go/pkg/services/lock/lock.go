@@ -0,0 +1,52 @@
+package lock
+
+import (
+	"fmt"
+	"time"
+)
+
+// Lock is a closed period. Transactions booked within the period must not be
+// re-categorized, hidden or deleted until the lock is removed.
+type Lock struct {
+	ID          int64     `json:"id"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// LockCreateRequest locks either the range from from to to or the calendar or fiscal month
+// of the passed month and year. The income category is only used for fiscal months.
+type LockCreateRequest struct {
+	From             *string `json:"from"`
+	To               *string `json:"to"`
+	Granularity      string  `json:"granularity"`
+	Month            int     `json:"month"`
+	Year             int     `json:"year"`
+	IncomeCategoryID int64   `json:"incomeCategoryID"`
+	Description      string  `json:"description"`
+}
+
+// Covering returns the first lock containing the passed date or nil, if the date is not locked.
+func Covering(locks []Lock, date time.Time) *Lock {
+	for i, lock := range locks {
+		if !date.Before(lock.Start) && !date.After(lock.End) {
+			return &locks[i]
+		}
+	}
+	return nil
+}
+
+// Check returns ErrPeriodLocked, if any of the passed dates is within one of the locks.
+func Check(locks []Lock, dates ...time.Time) error {
+	for _, date := range dates {
+		if lock := Covering(locks, date); lock != nil {
+			return lockedError(*lock)
+		}
+	}
+	return nil
+}
+
+func lockedError(lock Lock) error {
+	return fmt.Errorf("%w: %s to %s", ErrPeriodLocked, lock.Start.Format(time.DateOnly), lock.End.Format(time.DateOnly))
+}
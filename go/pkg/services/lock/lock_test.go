@@ -0,0 +1,65 @@
+package lock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Covering(t *testing.T) {
+	locks := []Lock{
+		{ID: 1, Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{ID: 2, Start: time.Date(2024, 3, 25, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 4, 24, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want int64
+	}{
+		{
+			name: "should include the first day",
+			date: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: 1,
+		},
+		{
+			name: "should include the last day",
+			date: time.Date(2024, 4, 24, 0, 0, 0, 0, time.UTC),
+			want: 2,
+		},
+		{
+			name: "should not lock dates between locks",
+			date: time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "should not lock dates after the last lock",
+			date: time.Date(2024, 4, 25, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Covering(locks, tt.date)
+			if tt.want == 0 {
+				assert.Nil(t, got)
+				return
+			}
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.want, got.ID)
+			}
+		})
+	}
+}
+
+func Test_Check(t *testing.T) {
+	locks := []Lock{
+		{ID: 1, Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	err := Check(locks, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 12, 31, 0, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+
+	err = Check(locks, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+	assert.ErrorIs(t, err, ErrPeriodLocked)
+}
@@ -0,0 +1,175 @@
+package lock
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrLockNotFound = errors.New("lock not found")
+	ErrInvalidLock  = errors.New("invalid lock")
+	ErrPeriodLocked = errors.New("period is locked")
+)
+
+// queryer is implemented by both database connections and transactions.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type Service struct {
+	db queryer
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+// WithTx returns a service running its queries within the passed database transaction,
+// so checks see the rows locked and changed by it.
+func (s *Service) WithTx(tx *sql.Tx) *Service {
+	return &Service{
+		db: tx,
+	}
+}
+
+const lockColumns = `id, start_date, end_date, description, created_at`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLock(row scanner) (*Lock, error) {
+	var lock Lock
+	err := row.Scan(&lock.ID, &lock.Start, &lock.End, &lock.Description, &lock.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func (s *Service) List() ([]Lock, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT %s
+		FROM period_locks
+		ORDER BY start_date, id;
+	`, lockColumns))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	locks := make([]Lock, 0)
+	for rows.Next() {
+		lock, err := scanLock(rows)
+		if err != nil {
+			return nil, err
+		}
+		locks = append(locks, *lock)
+	}
+
+	return locks, rows.Err()
+}
+
+func (s *Service) Get(id int64) (*Lock, error) {
+	lock, err := scanLock(s.db.QueryRow(fmt.Sprintf(`
+		SELECT %s
+		FROM period_locks
+		WHERE id = $1;
+	`, lockColumns), id))
+	if err == sql.ErrNoRows {
+		return nil, ErrLockNotFound
+	}
+	return lock, err
+}
+
+func (s *Service) Create(start, end time.Time, description string) (*Lock, error) {
+	if start.After(end) {
+		return nil, fmt.Errorf("%w: start must not be after end", ErrInvalidLock)
+	}
+
+	lock, err := scanLock(s.db.QueryRow(fmt.Sprintf(`
+		INSERT INTO period_locks (start_date, end_date, description)
+		VALUES ($1, $2, $3)
+		RETURNING %s;
+	`, lockColumns), start, end, description))
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Delete unlocks the period, so its transactions can be changed again.
+func (s *Service) Delete(id int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM period_locks
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrLockNotFound
+	}
+	return nil
+}
+
+// CheckTransaction returns ErrPeriodLocked, if the transaction is booked within a locked period.
+func (s *Service) CheckTransaction(transactionID int64) error {
+	return s.check(`
+		SELECT l.start_date, l.end_date
+		FROM transactions AS t
+		JOIN period_locks AS l ON t.booking_date BETWEEN l.start_date AND l.end_date
+		WHERE t.id = $1
+		ORDER BY l.start_date
+		LIMIT 1;
+	`, transactionID)
+}
+
+// CheckTransactions returns ErrPeriodLocked, if any of the transactions is booked within a locked period.
+func (s *Service) CheckTransactions(transactionIDs []int64) error {
+	return s.check(`
+		SELECT l.start_date, l.end_date
+		FROM transactions AS t
+		JOIN period_locks AS l ON t.booking_date BETWEEN l.start_date AND l.end_date
+		WHERE t.id = ANY($1)
+		ORDER BY l.start_date
+		LIMIT 1;
+	`, pq.Array(transactionIDs))
+}
+
+// CheckCategory returns ErrPeriodLocked, if any transaction of the category is booked within a locked period.
+func (s *Service) CheckCategory(categoryID int64) error {
+	return s.check(`
+		SELECT l.start_date, l.end_date
+		FROM transactions AS t
+		JOIN period_locks AS l ON t.booking_date BETWEEN l.start_date AND l.end_date
+		WHERE t.category_id = $1
+		ORDER BY l.start_date
+		LIMIT 1;
+	`, categoryID)
+}
+
+func (s *Service) check(query string, args ...any) error {
+	var lock Lock
+	err := s.db.QueryRow(query, args...).Scan(&lock.Start, &lock.End)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return lockedError(lock)
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/payee"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	Service         *payee.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         payee.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	payeesAPI := router.Group("/payees")
+	payeesAPI.GET("", handler.List)
+	payeesAPI.POST("", handler.Create)
+	payeesAPI.POST("/normalize", handler.Normalize)
+	payeesAPI.GET("/report", handler.Report)
+
+	payeeAPI := router.Group("/payee")
+	payeeAPI.GET("/:id", handler.Get)
+	payeeAPI.DELETE("/:id", handler.Delete)
+	payeeAPI.POST("/:id/aliases", handler.CreateAlias)
+	payeeAPI.DELETE("/:id/aliases/:aliasID", handler.DeleteAlias)
+
+	return handler
+}
+
+func (h *Handler) List(c *gin.Context) {
+	payees, err := h.Service.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payees)
+}
+
+func (h *Handler) Create(c *gin.Context) {
+	var createRequest payee.PayeeCreateRequest
+	err := c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee, err := h.Service.Create(createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, payee)
+}
+
+func (h *Handler) Normalize(c *gin.Context) {
+	result, err := h.Service.Normalize(audit.ActorFromRequest(c.Request))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) Report(c *gin.Context) {
+	from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sums, err := h.Service.Report(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sums)
+}
+
+func (h *Handler) Get(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payee, err := h.Service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, payee)
+}
+
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.Delete(audit.ActorFromRequest(c.Request), id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) CreateAlias(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createRequest payee.PayeeAliasCreateRequest
+	err = c.BindJSON(&createRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := h.Service.CreateAlias(id, createRequest.Regex)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, alias)
+}
+
+func (h *Handler) DeleteAlias(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	aliasID, err := strconv.ParseInt(c.Param("aliasID"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.Service.DeleteAlias(id, aliasID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, payee.ErrPayeeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, payee.ErrPayeeNameMissing),
+		errors.Is(err, payee.ErrInvalidAlias),
+		errors.Is(err, interval.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
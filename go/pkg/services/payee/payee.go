@@ -0,0 +1,71 @@
+package payee
+
+import (
+	"fmt"
+	"regexp"
+)
+
+type Payee struct {
+	ID      int64        `json:"id"`
+	Name    string       `json:"name"`
+	Aliases []PayeeAlias `json:"aliases,omitempty"`
+}
+
+// PayeeAlias maps raw recipients matching its regex to the canonical payee.
+type PayeeAlias struct {
+	ID      int64  `json:"id"`
+	PayeeID int64  `json:"payeeID"`
+	Regex   string `json:"regex"`
+}
+
+type PayeeCreateRequest struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+}
+
+type PayeeAliasCreateRequest struct {
+	Regex string `json:"regex"`
+}
+
+type PayeeSum struct {
+	Payee     *Payee  `json:"payee"`
+	Recipient *string `json:"recipient"`
+	Count     int64   `json:"count"`
+	Income    float64 `json:"income"`
+	Expenses  float64 `json:"expenses"`
+	Sum       float64 `json:"sum"`
+}
+
+type NormalizeResult struct {
+	Evaluated int `json:"evaluated"`
+	Changed   int `json:"changed"`
+}
+
+func (a *PayeeAlias) Match(recipient string) (bool, error) {
+	regex, err := regexp.Compile(fmt.Sprintf("(?i)%s", a.Regex))
+	if err != nil {
+		return false, err
+	}
+	return regex.MatchString(recipient), nil
+}
+
+// Normalize returns the first of the passed payees with an alias
+// matching the raw recipient, or nil if there is none.
+func Normalize(payees []Payee, recipient *string) (*Payee, error) {
+	if recipient == nil {
+		return nil, nil
+	}
+
+	for _, p := range payees {
+		for _, alias := range p.Aliases {
+			matches, err := alias.Match(*recipient)
+			if err != nil {
+				return nil, err
+			}
+			if matches {
+				return &Payee{ID: p.ID, Name: p.Name}, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,86 @@
+package payee
+
+import (
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Normalize(t *testing.T) {
+	payees := []Payee{
+		{
+			ID:   1,
+			Name: "Kaufland",
+			Aliases: []PayeeAlias{
+				{Regex: "^visa kaufland"},
+				{Regex: "kaufland filiale \\d+"},
+			},
+		},
+		{
+			ID:   2,
+			Name: "Telekom",
+			Aliases: []PayeeAlias{
+				{Regex: "telekom deutschland"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		payees    []Payee
+		recipient *string
+		want      *Payee
+		wantErr   bool
+	}{
+		{
+			name:      "should normalize card payment",
+			payees:    payees,
+			recipient: utils.NewString("VISA KAUFLAND MONSCHAU 8710"),
+			want:      &Payee{ID: 1, Name: "Kaufland"},
+		},
+		{
+			name:      "should normalize branch",
+			payees:    payees,
+			recipient: utils.NewString("Kaufland Filiale 1234"),
+			want:      &Payee{ID: 1, Name: "Kaufland"},
+		},
+		{
+			name:      "should normalize second payee",
+			payees:    payees,
+			recipient: utils.NewString("Telekom Deutschland GmbH"),
+			want:      &Payee{ID: 2, Name: "Telekom"},
+		},
+		{
+			name:      "should not normalize unknown recipient",
+			payees:    payees,
+			recipient: utils.NewString("Jan Muster"),
+			want:      nil,
+		},
+		{
+			name:      "should not normalize missing recipient",
+			payees:    payees,
+			recipient: nil,
+			want:      nil,
+		},
+		{
+			name:      "should fail on invalid alias",
+			payees:    []Payee{{ID: 3, Name: "Broken", Aliases: []PayeeAlias{{Regex: "("}}}},
+			recipient: utils.NewString("Jan Muster"),
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.payees, tt.recipient)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,401 @@
+package payee
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/database"
+	"docqube.de/bookkeeper/pkg/services/audit"
+)
+
+var (
+	ErrPayeeNotFound    = errors.New("payee not found")
+	ErrPayeeNameMissing = errors.New("payee name missing")
+	ErrInvalidAlias     = errors.New("invalid payee alias")
+)
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db: db,
+	}
+}
+
+func (s *Service) List() ([]Payee, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name
+		FROM payees
+		ORDER BY id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payees := make([]Payee, 0)
+	for rows.Next() {
+		var payee Payee
+		err = rows.Scan(&payee.ID, &payee.Name)
+		if err != nil {
+			return nil, err
+		}
+		payees = append(payees, payee)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range payees {
+		aliases, err := s.GetAliases(payees[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		payees[i].Aliases = aliases
+	}
+
+	return payees, nil
+}
+
+func (s *Service) Get(id int64) (*Payee, error) {
+	var payee Payee
+	err := s.db.QueryRow(`
+		SELECT id, name
+		FROM payees
+		WHERE id = $1;
+	`, id).Scan(&payee.ID, &payee.Name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPayeeNotFound
+		}
+		return nil, err
+	}
+
+	aliases, err := s.GetAliases(payee.ID)
+	if err != nil {
+		return nil, err
+	}
+	payee.Aliases = aliases
+
+	return &payee, nil
+}
+
+func (s *Service) GetAliases(payeeID int64) ([]PayeeAlias, error) {
+	rows, err := s.db.Query(`
+		SELECT id, payee_id, regex
+		FROM payee_aliases
+		WHERE payee_id = $1
+		ORDER BY id;
+	`, payeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := make([]PayeeAlias, 0)
+	for rows.Next() {
+		var alias PayeeAlias
+		err = rows.Scan(&alias.ID, &alias.PayeeID, &alias.Regex)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, alias)
+	}
+
+	return aliases, rows.Err()
+}
+
+// Create creates a payee with the passed alias regexes. Existing transactions
+// are only assigned to the new payee after calling Normalize.
+func (s *Service) Create(request PayeeCreateRequest) (*Payee, error) {
+	if request.Name == "" {
+		return nil, ErrPayeeNameMissing
+	}
+	for _, regex := range request.Aliases {
+		err := validateAlias(regex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`
+		INSERT INTO payees (name)
+		VALUES ($1)
+		RETURNING id;
+	`, request.Name).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, regex := range request.Aliases {
+		_, err = tx.Exec(`
+			INSERT INTO payee_aliases (payee_id, regex)
+			VALUES ($1, $2);
+		`, id, regex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Get(id)
+}
+
+// Delete deletes the payee with all of its aliases and
+// removes it from all assigned transactions.
+func (s *Service) Delete(actor audit.Actor, id int64) error {
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE transactions
+		SET payee_id = NULL
+		WHERE payee_id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM payee_aliases
+		WHERE payee_id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM payees
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPayeeNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (s *Service) CreateAlias(payeeID int64, regex string) (*PayeeAlias, error) {
+	err := validateAlias(regex)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.Get(payeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	alias := PayeeAlias{
+		PayeeID: payeeID,
+		Regex:   regex,
+	}
+	err = s.db.QueryRow(`
+		INSERT INTO payee_aliases (payee_id, regex)
+		VALUES ($1, $2)
+		RETURNING id;
+	`, payeeID, regex).Scan(&alias.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alias, nil
+}
+
+func (s *Service) DeleteAlias(payeeID, aliasID int64) error {
+	result, err := s.db.Exec(`
+		DELETE FROM payee_aliases
+		WHERE id = $1 AND payee_id = $2;
+	`, aliasID, payeeID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrPayeeNotFound
+	}
+	return nil
+}
+
+// Normalize assigns the matching payee to every transaction using the current
+// aliases, e.g. after adding a new payee or alias.
+func (s *Service) Normalize(actor audit.Actor) (*NormalizeResult, error) {
+	payees, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, recipient, payee_id
+		FROM transactions;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type assignment struct {
+		transactionID int64
+		payeeID       *int64
+	}
+
+	var result NormalizeResult
+	assignments := make([]assignment, 0)
+	for rows.Next() {
+		var (
+			transactionID  int64
+			recipient      sql.NullString
+			currentPayeeID sql.NullInt64
+		)
+		err = rows.Scan(&transactionID, &recipient, &currentPayeeID)
+		if err != nil {
+			return nil, err
+		}
+		result.Evaluated++
+
+		var rawRecipient *string
+		if recipient.Valid {
+			rawRecipient = &recipient.String
+		}
+		payee, err := Normalize(payees, rawRecipient)
+		if err != nil {
+			return nil, err
+		}
+
+		var payeeID *int64
+		if payee != nil {
+			payeeID = &payee.ID
+		}
+		if payeeID == nil && !currentPayeeID.Valid ||
+			payeeID != nil && currentPayeeID.Valid && *payeeID == currentPayeeID.Int64 {
+			continue
+		}
+		assignments = append(assignments, assignment{transactionID: transactionID, payeeID: payeeID})
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, a := range assignments {
+		_, err = tx.Exec(`
+			UPDATE transactions
+			SET payee_id = $1
+			WHERE id = $2;
+		`, a.payeeID, a.transactionID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	result.Changed = len(assignments)
+	return &result, nil
+}
+
+// Report returns the number of visible transactions booked between from and to and their
+// income, expenses and sum grouped by payee. Transactions without a payee are grouped
+// by their raw recipient instead.
+func (s *Service) Report(from, to time.Time) ([]PayeeSum, error) {
+	rows, err := s.db.Query(`
+		SELECT
+			p.id,
+			p.name,
+			CASE WHEN p.id IS NULL THEN t.recipient END AS raw_recipient,
+			COUNT(*),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.amount > 0), 0),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.amount < 0), 0),
+			SUM(t.amount)
+		FROM transactions AS t
+			LEFT JOIN payees AS p
+			ON t.payee_id = p.id
+		WHERE
+			t.booking_date BETWEEN $1 AND $2
+		AND
+			t.hidden = false
+		GROUP BY p.id, p.name, raw_recipient
+		ORDER BY SUM(t.amount) ASC;
+	`, database.NormalizeTime(from), database.NormalizeTime(to))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := make([]PayeeSum, 0)
+	for rows.Next() {
+		var (
+			sum          PayeeSum
+			payeeID      sql.NullInt64
+			payeeName    sql.NullString
+			rawRecipient sql.NullString
+		)
+		err = rows.Scan(&payeeID, &payeeName, &rawRecipient, &sum.Count, &sum.Income, &sum.Expenses, &sum.Sum)
+		if err != nil {
+			return nil, err
+		}
+
+		if payeeID.Valid {
+			sum.Payee = &Payee{ID: payeeID.Int64, Name: payeeName.String}
+		}
+		if rawRecipient.Valid {
+			sum.Recipient = &rawRecipient.String
+		}
+		sums = append(sums, sum)
+	}
+
+	return sums, rows.Err()
+}
+
+func validateAlias(regex string) error {
+	if regex == "" {
+		return fmt.Errorf("%w: regex missing", ErrInvalidAlias)
+	}
+	_, err := regexp.Compile(regex)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAlias, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+)
+
+var (
+	ErrUnknownBaseline = errors.New("unknown baseline")
+	ErrInvalidTrailing = errors.New("trailing periods must be positive")
+	// ErrUnsupportedGranularity is returned for granularities other than months, as the
+	// baselines are defined in months.
+	ErrUnsupportedGranularity = errors.New("comparisons only support month and fiscal_month")
+)
+
+// Baseline defines what the current period is compared against.
+type Baseline string
+
+const (
+	// BaselinePreviousPeriod compares against the previous month.
+	BaselinePreviousPeriod Baseline = "previous_period"
+	// BaselinePreviousYear compares against the same month of the previous year.
+	BaselinePreviousYear Baseline = "previous_year"
+	// BaselineTrailingAverage compares against the average of the preceding months.
+	BaselineTrailingAverage Baseline = "trailing_average"
+)
+
+func ParseBaseline(value string) (Baseline, error) {
+	switch baseline := Baseline(value); baseline {
+	case BaselinePreviousPeriod, BaselinePreviousYear, BaselineTrailingAverage:
+		return baseline, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownBaseline, value)
+}
+
+type ComparisonRequest struct {
+	Granularity      interval.Granularity
+	Month            int
+	Year             int
+	Baseline         Baseline
+	Trailing         int
+	Movers           int
+	IncomeCategoryID int64
+}
+
+// Delta compares the transactions of a category in the current period with the
+// baseline. The percent is nil, if the baseline has no transactions.
+type Delta struct {
+	Category *category.Category `json:"category"`
+	Current  Cell               `json:"current"`
+	Baseline Cell               `json:"baseline"`
+	Delta    float64            `json:"delta"`
+	Percent  *float64           `json:"percent"`
+}
+
+type Comparison struct {
+	Granularity     interval.Granularity `json:"granularity"`
+	Baseline        Baseline             `json:"baseline"`
+	Current         interval.Period      `json:"current"`
+	BaselinePeriods []interval.Period    `json:"baselinePeriods"`
+	Items           []Delta              `json:"items"`
+	// BiggestMovers are the items with the biggest absolute delta.
+	BiggestMovers []Delta `json:"biggestMovers"`
+}
+
+// validateComparisonGranularity returns ErrUnsupportedGranularity for granularities
+// whose periods can't be compared month by month.
+func validateComparisonGranularity(granularity interval.Granularity) error {
+	switch granularity {
+	case interval.GranularityMonth, interval.GranularityFiscalMonth:
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedGranularity, granularity)
+}
+
+// baselineOffsets returns the offsets in months of the periods the baseline consists of.
+func baselineOffsets(baseline Baseline, trailing int) ([]int, error) {
+	switch baseline {
+	case BaselinePreviousPeriod:
+		return []int{-1}, nil
+	case BaselinePreviousYear:
+		return []int{-12}, nil
+	case BaselineTrailingAverage:
+		if trailing <= 0 {
+			return nil, ErrInvalidTrailing
+		}
+		offsets := make([]int, trailing)
+		for i := range offsets {
+			offsets[i] = -(i + 1)
+		}
+		return offsets, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownBaseline, baseline)
+}
+
+// Compare calculates the delta of every category between the current period, which is the
+// period with index 0, and the average of the baseline periods, which are all other periods.
+// The deltas are ordered like the passed categories followed by the unclassified transactions.
+func Compare(categories []category.Category, periodCount int, sums []PeriodSum, movers int) ([]Delta, []Delta) {
+	current := make(map[int64]Cell)
+	baseline := make(map[int64]Cell)
+	var (
+		unclassifiedCurrent  Cell
+		unclassifiedBaseline Cell
+		hasUnclassified      bool
+	)
+	for _, sum := range sums {
+		if sum.CategoryID == nil {
+			hasUnclassified = true
+			if sum.PeriodIndex == 0 {
+				unclassifiedCurrent.add(sum.Cell)
+			} else {
+				unclassifiedBaseline.add(sum.Cell)
+			}
+			continue
+		}
+
+		cells := baseline
+		if sum.PeriodIndex == 0 {
+			cells = current
+		}
+		cell := cells[*sum.CategoryID]
+		cell.add(sum.Cell)
+		cells[*sum.CategoryID] = cell
+	}
+
+	baselineCount := periodCount - 1
+	items := make([]Delta, 0)
+	for _, c := range categories {
+		currentCell, hasCurrent := current[c.ID]
+		baselineCell, hasBaseline := baseline[c.ID]
+		if !hasCurrent && !hasBaseline {
+			continue
+		}
+		c.Rules = nil
+		items = append(items, newDelta(&c, currentCell, average(baselineCell, baselineCount)))
+	}
+	if hasUnclassified {
+		items = append(items, newDelta(nil, unclassifiedCurrent, average(unclassifiedBaseline, baselineCount)))
+	}
+
+	biggestMovers := make([]Delta, len(items))
+	copy(biggestMovers, items)
+	sort.SliceStable(biggestMovers, func(i, j int) bool {
+		return math.Abs(biggestMovers[i].Delta) > math.Abs(biggestMovers[j].Delta)
+	})
+	if movers < 0 {
+		movers = 0
+	}
+	if len(biggestMovers) > movers {
+		biggestMovers = biggestMovers[:movers]
+	}
+
+	return items, biggestMovers
+}
+
+func newDelta(c *category.Category, current, baseline Cell) Delta {
+	delta := Delta{
+		Category: c,
+		Current:  current,
+		Baseline: baseline,
+		Delta:    current.Net - baseline.Net,
+	}
+	if baseline.Net != 0 {
+		percent := delta.Delta / math.Abs(baseline.Net) * 100
+		delta.Percent = &percent
+	}
+	return delta
+}
+
+func average(cell Cell, count int) Cell {
+	if count <= 1 {
+		return cell
+	}
+	return Cell{
+		Count:    cell.Count / float64(count),
+		Income:   cell.Income / float64(count),
+		Expenses: cell.Expenses / float64(count),
+		Net:      cell.Net / float64(count),
+	}
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compare(t *testing.T) {
+	categories := []category.Category{
+		{ID: 1, Name: "Groceries"},
+		{ID: 2, Name: "Mobility"},
+		{ID: 3, Name: "Hobbies"},
+	}
+	// the current period has the index 0, the trailing average consists of two periods
+	sums := []PeriodSum{
+		{PeriodIndex: 0, CategoryID: utils.NewInt64(1), Cell: Cell{Count: 10, Expenses: -500, Net: -500}},
+		{PeriodIndex: 1, CategoryID: utils.NewInt64(1), Cell: Cell{Count: 8, Expenses: -400, Net: -400}},
+		{PeriodIndex: 2, CategoryID: utils.NewInt64(1), Cell: Cell{Count: 12, Expenses: -400, Net: -400}},
+		{PeriodIndex: 0, CategoryID: utils.NewInt64(2), Cell: Cell{Count: 1, Expenses: -60, Net: -60}},
+		{PeriodIndex: 2, CategoryID: utils.NewInt64(2), Cell: Cell{Count: 3, Expenses: -100, Net: -100}},
+		{PeriodIndex: 0, CategoryID: utils.NewInt64(3), Cell: Cell{Count: 1, Expenses: -20, Net: -20}},
+	}
+
+	items, biggestMovers := Compare(categories, 3, sums, 2)
+
+	groceries := Delta{
+		Category: &categories[0],
+		Current:  Cell{Count: 10, Expenses: -500, Net: -500},
+		Baseline: Cell{Count: 10, Expenses: -400, Net: -400},
+		Delta:    -100,
+		Percent:  utils.NewFloat64(-25),
+	}
+	mobility := Delta{
+		Category: &categories[1],
+		Current:  Cell{Count: 1, Expenses: -60, Net: -60},
+		Baseline: Cell{Count: 1.5, Expenses: -50, Net: -50},
+		Delta:    -10,
+		Percent:  utils.NewFloat64(-20),
+	}
+	hobbies := Delta{
+		Category: &categories[2],
+		Current:  Cell{Count: 1, Expenses: -20, Net: -20},
+		Delta:    -20,
+	}
+	assert.Equal(t, []Delta{groceries, mobility, hobbies}, items)
+	assert.Equal(t, []Delta{groceries, hobbies}, biggestMovers)
+}
+
+func Test_validateComparisonGranularity(t *testing.T) {
+	tests := []struct {
+		granularity interval.Granularity
+		wantErr     bool
+	}{
+		{granularity: interval.GranularityMonth},
+		{granularity: interval.GranularityFiscalMonth},
+		{granularity: interval.GranularityWeek, wantErr: true},
+		{granularity: interval.GranularityQuarter, wantErr: true},
+		{granularity: interval.GranularityYear, wantErr: true},
+		{granularity: interval.GranularityFiscalYear, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.granularity), func(t *testing.T) {
+			err := validateComparisonGranularity(tt.granularity)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsupportedGranularity)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/report"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTrailingPeriods = 12
+	defaultMovers          = 5
+)
+
+type Handler struct {
+	Service         *report.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         report.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	reportsAPI := router.Group("/reports")
+	reportsAPI.GET("/categories", handler.Categories)
+	reportsAPI.GET("/comparison", handler.Compare)
+
+	return handler
+}
+
+func (h *Handler) Categories(c *gin.Context) {
+	from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	granularity := interval.GranularityMonth
+	if rawGranularity := c.Query("granularity"); rawGranularity != "" {
+		granularity, err = interval.ParseGranularity(rawGranularity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// the income category overrides the configured fiscal settings
+	var incomeCategoryID int64
+	if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+		incomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.Service.Categories(granularity, from, to, incomeCategoryID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *Handler) Compare(c *gin.Context) {
+	request := report.ComparisonRequest{
+		Granularity: interval.GranularityFiscalMonth,
+		Baseline:    report.BaselinePreviousPeriod,
+		Trailing:    defaultTrailingPeriods,
+		Movers:      defaultMovers,
+	}
+
+	var err error
+	request.Year, err = strconv.Atoi(c.Query("year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request.Month, err = strconv.Atoi(c.Query("month"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rawGranularity := c.Query("granularity"); rawGranularity != "" {
+		request.Granularity, err = interval.ParseGranularity(rawGranularity)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if rawBaseline := c.Query("baseline"); rawBaseline != "" {
+		request.Baseline, err = report.ParseBaseline(rawBaseline)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if rawTrailing := c.Query("trailing"); rawTrailing != "" {
+		request.Trailing, err = strconv.Atoi(rawTrailing)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if rawMovers := c.Query("movers"); rawMovers != "" {
+		request.Movers, err = strconv.Atoi(rawMovers)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// the income category overrides the configured fiscal settings
+	if rawIncomeCategoryID := c.Query("income_category_id"); rawIncomeCategoryID != "" {
+		request.IncomeCategoryID, err = strconv.ParseInt(rawIncomeCategoryID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.Service.Compare(request)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, interval.ErrUnknownGranularity),
+		errors.Is(err, interval.ErrInvalidRange),
+		errors.Is(err, interval.ErrInvalidPeriod),
+		errors.Is(err, report.ErrUnknownBaseline),
+		errors.Is(err, report.ErrInvalidTrailing),
+		errors.Is(err, report.ErrUnsupportedGranularity),
+		errors.Is(err, report.ErrInvalidMonth):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
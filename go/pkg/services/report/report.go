@@ -0,0 +1,106 @@
+package report
+
+import (
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+)
+
+// Cell contains the aggregated transactions of a category within a period. The count
+// is only fractional for averages of several periods.
+type Cell struct {
+	Count    float64 `json:"count"`
+	Income   float64 `json:"income"`
+	Expenses float64 `json:"expenses"`
+	Net      float64 `json:"net"`
+}
+
+func (c *Cell) add(other Cell) {
+	c.Count += other.Count
+	c.Income += other.Income
+	c.Expenses += other.Expenses
+	c.Net += other.Net
+}
+
+// Row contains a cell for every period of the report. The category is nil for unclassified transactions.
+type Row struct {
+	Category *category.Category `json:"category"`
+	Cells    []Cell             `json:"cells"`
+	Total    Cell               `json:"total"`
+}
+
+// Report is a matrix of categories and periods. The cells of every row and
+// the totals are in the same order as the periods.
+type Report struct {
+	Granularity interval.Granularity `json:"granularity"`
+	Periods     []interval.Period    `json:"periods"`
+	Rows        []Row                `json:"rows"`
+	Totals      []Cell               `json:"totals"`
+	Total       Cell                 `json:"total"`
+}
+
+// PeriodSum is the aggregate of a category within the period with the passed index.
+type PeriodSum struct {
+	PeriodIndex int
+	CategoryID  *int64
+	Cell        Cell
+}
+
+// BuildReport builds the matrix from the aggregated sums. Rows are ordered like the passed
+// categories followed by the unclassified transactions, categories without sums are skipped.
+func BuildReport(granularity interval.Granularity, periods []interval.Period, categories []category.Category, sums []PeriodSum) Report {
+	report := Report{
+		Granularity: granularity,
+		Periods:     periods,
+		Rows:        make([]Row, 0),
+		Totals:      make([]Cell, len(periods)),
+	}
+
+	cells := make(map[int64][]Cell)
+	var unclassified []Cell
+	for _, sum := range sums {
+		if sum.PeriodIndex < 0 || sum.PeriodIndex >= len(periods) {
+			continue
+		}
+
+		var row []Cell
+		if sum.CategoryID == nil {
+			if unclassified == nil {
+				unclassified = make([]Cell, len(periods))
+			}
+			row = unclassified
+		} else {
+			if _, ok := cells[*sum.CategoryID]; !ok {
+				cells[*sum.CategoryID] = make([]Cell, len(periods))
+			}
+			row = cells[*sum.CategoryID]
+		}
+		row[sum.PeriodIndex].add(sum.Cell)
+		report.Totals[sum.PeriodIndex].add(sum.Cell)
+		report.Total.add(sum.Cell)
+	}
+
+	for _, c := range categories {
+		row, ok := cells[c.ID]
+		if !ok {
+			continue
+		}
+		c.Rules = nil
+		report.Rows = append(report.Rows, newRow(&c, row))
+	}
+	if unclassified != nil {
+		report.Rows = append(report.Rows, newRow(nil, unclassified))
+	}
+
+	return report
+}
+
+func newRow(c *category.Category, cells []Cell) Row {
+	row := Row{
+		Category: c,
+		Cells:    cells,
+	}
+	for _, cell := range cells {
+		row.Total.add(cell)
+	}
+	return row
+}
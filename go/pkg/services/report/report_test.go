@@ -0,0 +1,55 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_BuildReport(t *testing.T) {
+	periods := []interval.Period{
+		{Key: "2024-01", Start: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{Key: "2024-02", Start: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	categories := []category.Category{
+		{ID: 1, Name: "Income", Rules: []category.CategoryRule{{ID: 1, Regex: "gehalt"}}},
+		{ID: 2, Name: "Groceries"},
+		{ID: 3, Name: "Unused"},
+	}
+	sums := []PeriodSum{
+		{PeriodIndex: 0, CategoryID: utils.NewInt64(1), Cell: Cell{Count: 1, Income: 3000, Net: 3000}},
+		{PeriodIndex: 0, CategoryID: utils.NewInt64(2), Cell: Cell{Count: 4, Income: 10, Expenses: -210, Net: -200}},
+		{PeriodIndex: 1, CategoryID: utils.NewInt64(2), Cell: Cell{Count: 2, Expenses: -90, Net: -90}},
+		{PeriodIndex: 1, CategoryID: nil, Cell: Cell{Count: 1, Expenses: -15, Net: -15}},
+	}
+
+	got := BuildReport(interval.GranularityMonth, periods, categories, sums)
+
+	assert.Equal(t, Report{
+		Granularity: interval.GranularityMonth,
+		Periods:     periods,
+		Rows: []Row{
+			{
+				Category: &category.Category{ID: 1, Name: "Income"},
+				Cells:    []Cell{{Count: 1, Income: 3000, Net: 3000}, {}},
+				Total:    Cell{Count: 1, Income: 3000, Net: 3000},
+			},
+			{
+				Category: &category.Category{ID: 2, Name: "Groceries"},
+				Cells:    []Cell{{Count: 4, Income: 10, Expenses: -210, Net: -200}, {Count: 2, Expenses: -90, Net: -90}},
+				Total:    Cell{Count: 6, Income: 10, Expenses: -300, Net: -290},
+			},
+			{
+				Category: nil,
+				Cells:    []Cell{{}, {Count: 1, Expenses: -15, Net: -15}},
+				Total:    Cell{Count: 1, Expenses: -15, Net: -15},
+			},
+		},
+		Totals: []Cell{{Count: 5, Income: 3010, Expenses: -210, Net: 2800}, {Count: 3, Expenses: -105, Net: -105}},
+		Total:  Cell{Count: 8, Income: 3010, Expenses: -315, Net: 2695},
+	}, got)
+}
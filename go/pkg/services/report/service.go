@@ -0,0 +1,158 @@
+package report
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/database"
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvalidMonth = errors.New("month must be between 1 and 12")
+)
+
+type Service struct {
+	db              *sql.DB
+	categoryService *category.Service
+	intervalService *interval.Service
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db:              db,
+		categoryService: category.NewService(db),
+		intervalService: interval.NewService(db),
+	}
+}
+
+// Categories returns the income, expenses and net of every category per period of the
+// granularity between from and to. Hidden transactions and transfers are excluded.
+func (s *Service) Categories(granularity interval.Granularity, from, to time.Time, incomeCategoryID int64) (*Report, error) {
+	periods, err := s.intervalService.Periods(granularity, from, to, incomeCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := s.sumPeriods(periods)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryService.List(true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := BuildReport(granularity, periods, categories, sums)
+	return &report, nil
+}
+
+// Compare compares the transactions of every category in the passed month with the
+// baseline of the request. Hidden transactions and transfers are excluded.
+func (s *Service) Compare(request ComparisonRequest) (*Comparison, error) {
+	if request.Month < 1 || request.Month > 12 {
+		return nil, ErrInvalidMonth
+	}
+	err := validateComparisonGranularity(request.Granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	offsets, err := baselineOffsets(request.Baseline, request.Trailing)
+	if err != nil {
+		return nil, err
+	}
+
+	// the current period is the first one, followed by the baseline periods
+	periods := make([]interval.Period, 0, len(offsets)+1)
+	for _, offset := range append([]int{0}, offsets...) {
+		month := time.Date(request.Year, time.Month(request.Month+offset), 1, 0, 0, 0, 0, time.UTC)
+		period, err := s.intervalService.MonthPeriod(request.Granularity, int(month.Month()), month.Year(), request.IncomeCategoryID)
+		if err != nil {
+			return nil, err
+		}
+		periods = append(periods, *period)
+	}
+
+	sums, err := s.sumPeriods(periods)
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.categoryService.List(true)
+	if err != nil {
+		return nil, err
+	}
+
+	items, biggestMovers := Compare(categories, len(periods), sums, request.Movers)
+	return &Comparison{
+		Granularity:     request.Granularity,
+		Baseline:        request.Baseline,
+		Current:         periods[0],
+		BaselinePeriods: periods[1:],
+		Items:           items,
+		BiggestMovers:   biggestMovers,
+	}, nil
+}
+
+// sumPeriods aggregates the transactions of every category within the passed periods
+// in a single query. The returned period indexes refer to the passed periods.
+func (s *Service) sumPeriods(periods []interval.Period) ([]PeriodSum, error) {
+	if len(periods) == 0 {
+		return []PeriodSum{}, nil
+	}
+
+	starts := make([]string, len(periods))
+	ends := make([]string, len(periods))
+	for i, period := range periods {
+		starts[i] = database.NormalizeTime(period.Start).Format(time.DateOnly)
+		ends[i] = database.NormalizeTime(period.End).Format(time.DateOnly)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+			p.idx - 1,
+			t.category_id,
+			COUNT(*),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.amount > 0), 0),
+			COALESCE(SUM(t.amount) FILTER (WHERE t.amount < 0), 0),
+			SUM(t.amount)
+		FROM unnest($1::date[], $2::date[]) WITH ORDINALITY AS p(start_date, end_date, idx)
+			JOIN transactions AS t
+			ON t.booking_date BETWEEN p.start_date AND p.end_date
+			LEFT JOIN categories AS c
+			ON t.category_id = c.id
+		WHERE
+			t.hidden = false
+		AND
+			c.transfer IS NOT TRUE
+		GROUP BY p.idx, t.category_id
+		ORDER BY p.idx, t.category_id;
+	`, pq.Array(starts), pq.Array(ends))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := make([]PeriodSum, 0)
+	for rows.Next() {
+		var (
+			sum        PeriodSum
+			categoryID sql.NullInt64
+		)
+		err = rows.Scan(&sum.PeriodIndex, &categoryID, &sum.Cell.Count, &sum.Cell.Income, &sum.Cell.Expenses, &sum.Cell.Net)
+		if err != nil {
+			return nil, err
+		}
+		if categoryID.Valid {
+			sum.CategoryID = &categoryID.Int64
+		}
+		sums = append(sums, sum)
+	}
+
+	return sums, rows.Err()
+}
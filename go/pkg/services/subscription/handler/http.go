@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/subscription"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultDetectionYears is the number of years scanned for recurring payments, if no range is passed.
+const defaultDetectionYears = 2
+
+type Handler struct {
+	Service         *subscription.Service
+	IntervalService *interval.Service
+}
+
+func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
+	handler := &Handler{
+		Service:         subscription.NewService(db),
+		IntervalService: interval.NewService(db),
+	}
+
+	subscriptionsAPI := router.Group("/subscriptions")
+	subscriptionsAPI.GET("", handler.List)
+	subscriptionsAPI.POST("/detect", handler.Detect)
+
+	return handler
+}
+
+func (h *Handler) List(c *gin.Context) {
+	subscriptions, err := h.Service.List(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if rawFlagged := c.Query("flagged"); rawFlagged != "" {
+		flagged, err := strconv.ParseBool(rawFlagged)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		filtered := make([]subscription.Subscription, 0)
+		for _, s := range subscriptions {
+			if (s.PriceIncrease || s.Missing) == flagged {
+				filtered = append(filtered, s)
+			}
+		}
+		subscriptions = filtered
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+func (h *Handler) Detect(c *gin.Context) {
+	now := time.Now()
+	from, to, err := h.IntervalService.Range(
+		c.Query("period"),
+		c.DefaultQuery("from", now.AddDate(-defaultDetectionYears, 0, 0).Format(time.DateOnly)),
+		c.DefaultQuery("to", now.Format(time.DateOnly)),
+	)
+	if err != nil {
+		if errors.Is(err, interval.ErrInvalidPeriod) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.Service.Detect(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/subscription"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Handler_Detect_InvalidPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// invalid ranges are rejected before the database is used
+	handler := &Handler{
+		Service:         subscription.NewService(nil),
+		IntervalService: interval.NewService(nil),
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "should reject an invalid period", query: "?period=someday"},
+		{name: "should reject an invalid date", query: "?to=2024-02-30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPost, "/subscriptions/detect"+tt.query, nil)
+
+			handler.Detect(c)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package subscription
+
+import (
+	"database/sql"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/database"
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+)
+
+type Service struct {
+	db                 *sql.DB
+	calendarService    *calendar.Service
+	transactionService *transaction.Service
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db:                 db,
+		calendarService:    calendar.NewService(db),
+		transactionService: transaction.NewService(db),
+	}
+}
+
+// Detect scans all transactions booked between from and to for recurring payments
+// and replaces the previously detected subscriptions with the result.
+func (s *Service) Detect(from, to time.Time) (*DetectResult, error) {
+	transactions, err := s.transactionService.List(transaction.Filter{From: &from, To: &to}, transaction.Chronological, transaction.Page{})
+	if err != nil {
+		return nil, err
+	}
+	subscriptions := Detect(transactions.Items)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM subscriptions;`)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subscription := range subscriptions {
+		_, err = tx.Exec(`
+			INSERT INTO subscriptions (
+				key,
+				recipient,
+				payee_id,
+				interval,
+				amount,
+				previous_amount,
+				occurrences,
+				last_date,
+				next_date
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+		`,
+			subscription.Key,
+			subscription.Recipient,
+			subscription.PayeeID,
+			subscription.Interval,
+			subscription.Amount,
+			subscription.PreviousAmount,
+			subscription.Occurrences,
+			database.NormalizeTime(subscription.LastDate),
+			database.NormalizeTime(subscription.NextDate),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DetectResult{
+		Evaluated:     len(transactions.Items),
+		Subscriptions: len(subscriptions),
+	}, nil
+}
+
+// List returns all detected subscriptions ordered by their next expected date
+// and flags price increases and missing payments at the passed date.
+func (s *Service) List(now time.Time) ([]Subscription, error) {
+	cal, err := s.calendarService.Calendar()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT
+			id,
+			key,
+			recipient,
+			payee_id,
+			interval,
+			amount,
+			previous_amount,
+			occurrences,
+			last_date,
+			next_date
+		FROM subscriptions
+		ORDER BY next_date, id;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]Subscription, 0)
+	for rows.Next() {
+		var (
+			subscription Subscription
+			payeeID      sql.NullInt64
+		)
+		err = rows.Scan(
+			&subscription.ID,
+			&subscription.Key,
+			&subscription.Recipient,
+			&payeeID,
+			&subscription.Interval,
+			&subscription.Amount,
+			&subscription.PreviousAmount,
+			&subscription.Occurrences,
+			&subscription.LastDate,
+			&subscription.NextDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if payeeID.Valid {
+			subscription.PayeeID = &payeeID.Int64
+		}
+		subscription.Flag(now, cal)
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
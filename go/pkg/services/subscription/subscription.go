@@ -0,0 +1,280 @@
+package subscription
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"docqube.de/bookkeeper/pkg/utils"
+)
+
+type Interval string
+
+const (
+	IntervalWeekly    Interval = "weekly"
+	IntervalMonthly   Interval = "monthly"
+	IntervalQuarterly Interval = "quarterly"
+	IntervalYearly    Interval = "yearly"
+)
+
+// intervals are ordered by their length, so the shortest matching interval wins.
+var intervals = []Interval{IntervalWeekly, IntervalMonthly, IntervalQuarterly, IntervalYearly}
+
+// Days returns the nominal number of days between two payments of the interval.
+func (i Interval) Days() int {
+	switch i {
+	case IntervalWeekly:
+		return 7
+	case IntervalMonthly:
+		return 30
+	case IntervalQuarterly:
+		return 91
+	case IntervalYearly:
+		return 365
+	}
+	return 0
+}
+
+// Tolerance returns the number of days a payment may be booked before or after the nominal
+// date, as banks shift bookings on weekends and holidays and months differ in length.
+func (i Interval) Tolerance() int {
+	switch i {
+	case IntervalWeekly:
+		return 2
+	case IntervalMonthly:
+		return 4
+	case IntervalQuarterly:
+		return 7
+	case IntervalYearly:
+		return 10
+	}
+	return 0
+}
+
+// MinOccurrences returns the number of payments required to detect a recurring payment.
+func (i Interval) MinOccurrences() int {
+	switch i {
+	case IntervalWeekly:
+		return 4
+	case IntervalYearly:
+		return 2
+	}
+	return 3
+}
+
+// Next returns the date the payment following the passed date is expected.
+func (i Interval) Next(date time.Time) time.Time {
+	switch i {
+	case IntervalWeekly:
+		return date.AddDate(0, 0, 7)
+	case IntervalMonthly:
+		return addMonths(date, 1)
+	case IntervalQuarterly:
+		return addMonths(date, 3)
+	case IntervalYearly:
+		return addMonths(date, 12)
+	}
+	return date
+}
+
+// Subscription is a payment or income recurring in a regular interval.
+type Subscription struct {
+	ID             int64     `json:"id"`
+	Key            string    `json:"key"`
+	Recipient      string    `json:"recipient"`
+	PayeeID        *int64    `json:"payeeID"`
+	Interval       Interval  `json:"interval"`
+	Amount         float64   `json:"amount"`
+	PreviousAmount float64   `json:"previousAmount"`
+	Occurrences    int       `json:"occurrences"`
+	LastDate       time.Time `json:"lastDate"`
+	NextDate       time.Time `json:"nextDate"`
+	// ExpectedDate is the business day the next payment is expected to be booked on.
+	ExpectedDate time.Time `json:"expectedDate"`
+
+	// PriceIncrease is set, if the last payment is higher than the one before.
+	PriceIncrease bool `json:"priceIncrease"`
+	// Missing is set, if the expected payment has not arrived within the tolerance of the interval.
+	Missing bool `json:"missing"`
+}
+
+type DetectResult struct {
+	Evaluated     int `json:"evaluated"`
+	Subscriptions int `json:"subscriptions"`
+}
+
+const (
+	// amountTolerance is the relative difference allowed between two payments of a subscription.
+	amountTolerance = 0.25
+	// regularityThreshold is the share of gaps that have to match the interval.
+	regularityThreshold = 0.75
+)
+
+var nonLetterRegex = regexp.MustCompile(`[^\pL]+`)
+
+// Key returns the key used to group the payments of a subscription. It is the name
+// of the payee or, if no payee is assigned, the recipient without numbers and punctuation.
+func Key(t transaction.Transaction) string {
+	if t.Payee != nil {
+		return "payee:" + strings.ToLower(t.Payee.Name)
+	}
+	if t.Recipient == nil {
+		return ""
+	}
+	key := strings.TrimSpace(nonLetterRegex.ReplaceAllString(strings.ToLower(*t.Recipient), " "))
+	if key == "" {
+		return ""
+	}
+	return "recipient:" + key
+}
+
+// Detect finds all recurring payments and incomes within the passed transactions. Payments
+// are grouped by their key and amount first, afterwards the interval is determined by the
+// median gap between two payments.
+func Detect(transactions []transaction.Transaction) []Subscription {
+	sorted := make([]transaction.Transaction, len(transactions))
+	copy(sorted, transactions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].BookingDate.Before(sorted[j].BookingDate)
+	})
+
+	groups := make(map[string][][]transaction.Transaction)
+	keys := make([]string, 0)
+	for _, t := range sorted {
+		key := Key(t)
+		if key == "" || t.Hidden || t.Amount == 0 {
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = appendToCluster(groups[key], t)
+	}
+
+	subscriptions := make([]Subscription, 0)
+	for _, key := range keys {
+		for _, cluster := range groups[key] {
+			subscription, ok := detectInterval(key, cluster)
+			if ok {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+	}
+
+	return subscriptions
+}
+
+// appendToCluster appends the transaction to the first cluster whose last amount is similar.
+// Comparing against the last amount keeps subscriptions with gradual price increases together.
+func appendToCluster(clusters [][]transaction.Transaction, t transaction.Transaction) [][]transaction.Transaction {
+	for i, cluster := range clusters {
+		last := cluster[len(cluster)-1]
+		if similarAmount(last.Amount, t.Amount) {
+			clusters[i] = append(cluster, t)
+			return clusters
+		}
+	}
+	return append(clusters, []transaction.Transaction{t})
+}
+
+func similarAmount(a, b float64) bool {
+	if a < 0 != (b < 0) {
+		return false
+	}
+	return math.Abs(a-b) <= amountTolerance*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func detectInterval(key string, cluster []transaction.Transaction) (Subscription, bool) {
+	if len(cluster) < 2 {
+		return Subscription{}, false
+	}
+
+	gaps := make([]int, 0, len(cluster)-1)
+	for i := 1; i < len(cluster); i++ {
+		gaps = append(gaps, days(cluster[i].BookingDate.Sub(cluster[i-1].BookingDate)))
+	}
+	sortedGaps := make([]int, len(gaps))
+	copy(sortedGaps, gaps)
+	sort.Ints(sortedGaps)
+	median := sortedGaps[len(sortedGaps)/2]
+
+	for _, interval := range intervals {
+		if len(cluster) < interval.MinOccurrences() || !withinTolerance(median, interval) {
+			continue
+		}
+
+		regular := 0
+		for _, gap := range gaps {
+			if withinTolerance(gap, interval) {
+				regular++
+			}
+		}
+		if float64(regular) < regularityThreshold*float64(len(gaps)) {
+			return Subscription{}, false
+		}
+
+		last := cluster[len(cluster)-1]
+		previous := cluster[len(cluster)-2]
+		subscription := Subscription{
+			Key:            key,
+			Interval:       interval,
+			Amount:         last.Amount,
+			PreviousAmount: previous.Amount,
+			Occurrences:    len(cluster),
+			LastDate:       last.BookingDate,
+			NextDate:       interval.Next(last.BookingDate),
+		}
+		if last.Recipient != nil {
+			subscription.Recipient = *last.Recipient
+		}
+		if last.Payee != nil {
+			subscription.Recipient = last.Payee.Name
+			subscription.PayeeID = &last.Payee.ID
+		}
+		return subscription, true
+	}
+
+	return Subscription{}, false
+}
+
+// Flag sets the expected date as well as the price increase and missing flags of the subscription for the passed date.
+func (s *Subscription) Flag(now time.Time, cal *calendar.Calendar) {
+	// amounts of expenses are negative, so the absolute values are compared
+	s.PriceIncrease = math.Abs(s.Amount)-math.Abs(s.PreviousAmount) > 0.005
+	s.ExpectedDate = BookingDate(cal, s.NextDate, s.Amount)
+	s.Missing = now.After(s.ExpectedDate.AddDate(0, 0, s.Interval.Tolerance()))
+}
+
+// BookingDate returns the business day a payment due on the passed date is booked on. Incomes
+// like salaries are paid on the preceding business day, payments are debited on the following one.
+func BookingDate(cal *calendar.Calendar, due time.Time, amount float64) time.Time {
+	if amount > 0 {
+		return cal.PreviousBusinessDay(due)
+	}
+	return cal.NextBusinessDay(due)
+}
+
+func withinTolerance(gap int, interval Interval) bool {
+	return utils.Abs(gap-interval.Days()) <= interval.Tolerance()
+}
+
+func days(d time.Duration) int {
+	return int(math.Round(d.Hours() / 24))
+}
+
+// addMonths adds the passed number of months, but clamps the day to the
+// last day of the resulting month instead of overflowing into the next one.
+func addMonths(date time.Time, months int) time.Time {
+	firstOfMonth := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()).AddDate(0, months, 0)
+	lastDay := firstOfMonth.AddDate(0, 1, -1).Day()
+
+	day := date.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(firstOfMonth.Year(), firstOfMonth.Month(), day, 0, 0, 0, 0, date.Location())
+}
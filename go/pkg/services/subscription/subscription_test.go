@@ -0,0 +1,149 @@
+package subscription
+
+import (
+	"testing"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/services/calendar"
+	"docqube.de/bookkeeper/pkg/services/payee"
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func Test_Detect(t *testing.T) {
+	transactions := []transaction.Transaction{
+		// monthly subscription with a price increase, booked on different days
+		{BookingDate: date(2024, 1, 15), Recipient: utils.NewString("NETFLIX.COM 1234"), Amount: -12.99},
+		{BookingDate: date(2024, 2, 16), Recipient: utils.NewString("NETFLIX.COM 5678"), Amount: -12.99},
+		{BookingDate: date(2024, 3, 15), Recipient: utils.NewString("NETFLIX.COM 9012"), Amount: -12.99},
+		{BookingDate: date(2024, 4, 15), Recipient: utils.NewString("NETFLIX.COM 3456"), Amount: -13.99},
+		// irregular purchases at the same recipient with a different amount
+		{BookingDate: date(2024, 1, 3), Recipient: utils.NewString("NETFLIX.COM"), Amount: -99.00},
+		{BookingDate: date(2024, 3, 20), Recipient: utils.NewString("NETFLIX.COM"), Amount: -101.00},
+		// monthly income normalized to a payee
+		{BookingDate: date(2024, 1, 31), Recipient: utils.NewString("ACME GMBH"), Payee: &payee.Payee{ID: 7, Name: "ACME"}, Amount: 3000},
+		{BookingDate: date(2024, 2, 29), Recipient: utils.NewString("ACME GmbH"), Payee: &payee.Payee{ID: 7, Name: "ACME"}, Amount: 3000},
+		{BookingDate: date(2024, 3, 28), Recipient: utils.NewString("ACME"), Payee: &payee.Payee{ID: 7, Name: "ACME"}, Amount: 3000},
+		// yearly insurance
+		{BookingDate: date(2023, 1, 2), Recipient: utils.NewString("HUK-COBURG"), Amount: -480},
+		{BookingDate: date(2024, 1, 2), Recipient: utils.NewString("HUK-COBURG"), Amount: -495},
+		// hidden transactions are ignored
+		{BookingDate: date(2024, 1, 1), Recipient: utils.NewString("Savings"), Amount: -100, Hidden: true},
+		{BookingDate: date(2024, 2, 1), Recipient: utils.NewString("Savings"), Amount: -100, Hidden: true},
+		{BookingDate: date(2024, 3, 1), Recipient: utils.NewString("Savings"), Amount: -100, Hidden: true},
+	}
+
+	assert.Equal(t, []Subscription{
+		{
+			Key:            "recipient:huk coburg",
+			Recipient:      "HUK-COBURG",
+			Interval:       IntervalYearly,
+			Amount:         -495,
+			PreviousAmount: -480,
+			Occurrences:    2,
+			LastDate:       date(2024, 1, 2),
+			NextDate:       date(2025, 1, 2),
+		},
+		{
+			Key:            "recipient:netflix com",
+			Recipient:      "NETFLIX.COM 3456",
+			Interval:       IntervalMonthly,
+			Amount:         -13.99,
+			PreviousAmount: -12.99,
+			Occurrences:    4,
+			LastDate:       date(2024, 4, 15),
+			NextDate:       date(2024, 5, 15),
+		},
+		{
+			Key:            "payee:acme",
+			Recipient:      "ACME",
+			PayeeID:        utils.NewInt64(7),
+			Interval:       IntervalMonthly,
+			Amount:         3000,
+			PreviousAmount: 3000,
+			Occurrences:    3,
+			LastDate:       date(2024, 3, 28),
+			NextDate:       date(2024, 4, 28),
+		},
+	}, Detect(transactions))
+}
+
+func Test_Subscription_Flag(t *testing.T) {
+	tests := []struct {
+		name              string
+		subscription      Subscription
+		now               time.Time
+		wantPriceIncrease bool
+		wantMissing       bool
+	}{
+		{
+			name:              "should flag higher expenses",
+			subscription:      Subscription{Interval: IntervalMonthly, Amount: -13.99, PreviousAmount: -12.99, NextDate: date(2024, 5, 15)},
+			now:               date(2024, 5, 1),
+			wantPriceIncrease: true,
+			wantMissing:       false,
+		},
+		{
+			name:              "should not flag lower expenses",
+			subscription:      Subscription{Interval: IntervalMonthly, Amount: -9.99, PreviousAmount: -12.99, NextDate: date(2024, 5, 15)},
+			now:               date(2024, 5, 19),
+			wantPriceIncrease: false,
+			wantMissing:       false,
+		},
+		{
+			name:              "should flag missing payments after the tolerance",
+			subscription:      Subscription{Interval: IntervalMonthly, Amount: -12.99, PreviousAmount: -12.99, NextDate: date(2024, 5, 15)},
+			now:               date(2024, 5, 20),
+			wantPriceIncrease: false,
+			wantMissing:       true,
+		},
+		{
+			name:              "should expect payments due on a holiday on the next business day",
+			subscription:      Subscription{Interval: IntervalMonthly, Amount: -12.99, PreviousAmount: -12.99, NextDate: date(2024, 12, 25)},
+			now:               date(2024, 12, 31),
+			wantPriceIncrease: false,
+			wantMissing:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.subscription.Flag(tt.now, calendar.New(calendar.StateNone))
+			assert.Equal(t, tt.wantPriceIncrease, tt.subscription.PriceIncrease)
+			assert.Equal(t, tt.wantMissing, tt.subscription.Missing)
+		})
+	}
+}
+
+func Test_BookingDate(t *testing.T) {
+	cal := calendar.New(calendar.StateBayern)
+	tests := []struct {
+		name   string
+		due    time.Time
+		amount float64
+		want   time.Time
+	}{
+		{name: "should keep business days", due: date(2024, 5, 15), amount: -10, want: date(2024, 5, 15)},
+		{name: "should debit payments due on a weekend on monday", due: date(2024, 6, 1), amount: -10, want: date(2024, 6, 3)},
+		{name: "should pay incomes due on a weekend on friday", due: date(2024, 6, 1), amount: 2500, want: date(2024, 5, 31)},
+		{name: "should skip state holidays", due: date(2024, 11, 1), amount: -10, want: date(2024, 11, 4)},
+		{name: "should pay salaries before christmas", due: date(2024, 12, 26), amount: 2500, want: date(2024, 12, 24)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BookingDate(cal, tt.due, tt.amount))
+		})
+	}
+}
+
+func Test_Interval_Next(t *testing.T) {
+	assert.Equal(t, date(2024, 2, 29), IntervalMonthly.Next(date(2024, 1, 31)))
+	assert.Equal(t, date(2024, 4, 30), IntervalQuarterly.Next(date(2024, 1, 31)))
+	assert.Equal(t, date(2024, 1, 8), IntervalWeekly.Next(date(2024, 1, 1)))
+}
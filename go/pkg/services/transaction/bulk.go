@@ -0,0 +1,61 @@
+package transaction
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// BulkPatchRequest patches all selected transactions. The transactions are selected by their IDs
+// and the filter passed along.
+type BulkPatchRequest struct {
+	IDs []int64 `json:"ids"`
+	TransactionPatchRequest
+	// DryRun only counts the transactions, which would be patched.
+	DryRun bool `json:"dryRun"`
+}
+
+type BulkPatchResult struct {
+	Affected int     `json:"affected"`
+	IDs      []int64 `json:"ids"`
+	DryRun   bool    `json:"dryRun"`
+}
+
+// changesBooking returns true, if the patch changes how the transactions are booked, which is
+// not allowed within locked periods. Notes and tags can always be changed.
+func (p TransactionPatchRequest) changesBooking() bool {
+	return p.CategoryID != nil || p.Hidden != nil
+}
+
+// RemovesCategory returns true, if the patch removes the category of the transactions.
+func (p TransactionPatchRequest) RemovesCategory() bool {
+	return p.CategoryID != nil && *p.CategoryID == 0
+}
+
+// assignments returns the assignments of the update applying the patch.
+func (b *queryBuilder) assignments(patch TransactionPatchRequest) (string, error) {
+	assignments := make([]string, 0)
+	if patch.RemovesCategory() {
+		assignments = append(assignments, "category_id = NULL")
+	} else if patch.CategoryID != nil {
+		assignments = append(assignments, "category_id = "+b.arg(*patch.CategoryID))
+	}
+	if patch.Hidden != nil {
+		assignments = append(assignments, "hidden = "+b.arg(*patch.Hidden))
+	}
+	if patch.Note != nil {
+		var note *string
+		if value := strings.TrimSpace(*patch.Note); value != "" {
+			note = &value
+		}
+		assignments = append(assignments, "note = "+b.arg(note))
+	}
+	if patch.Tags != nil {
+		assignments = append(assignments, "tags = "+b.arg(pq.Array(NormalizeTags(*patch.Tags))))
+	}
+	if len(assignments) == 0 {
+		return "", fmt.Errorf("%w: nothing to change", ErrInvalidTransaction)
+	}
+	return strings.Join(assignments, ", "), nil
+}
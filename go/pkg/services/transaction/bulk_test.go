@@ -0,0 +1,90 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_queryBuilder_assignments(t *testing.T) {
+	tests := []struct {
+		name            string
+		patch           TransactionPatchRequest
+		wantAssignments string
+		wantArgs        []any
+		wantErr         error
+	}{
+		{
+			name: "should categorize and hide",
+			patch: TransactionPatchRequest{
+				CategoryID: utils.NewInt64(3),
+				Hidden:     utils.NewBool(true),
+			},
+			wantAssignments: "category_id = $1, hidden = $2",
+			wantArgs:        []any{int64(3), true},
+		},
+		{
+			name: "should uncategorize and remove an empty note",
+			patch: TransactionPatchRequest{
+				CategoryID: utils.NewInt64(0),
+				Note:       utils.NewString("  "),
+			},
+			wantAssignments: "category_id = NULL, note = $1",
+			wantArgs:        []any{(*string)(nil)},
+		},
+		{
+			name: "should replace normalized tags",
+			patch: TransactionPatchRequest{
+				Tags: &[]string{"Trip", " trip", "Work"},
+			},
+			wantAssignments: "tags = $1",
+			wantArgs:        []any{pq.Array([]string{"trip", "work"})},
+		},
+		{
+			name:    "should fail without changes",
+			patch:   TransactionPatchRequest{},
+			wantErr: ErrInvalidTransaction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder queryBuilder
+			got, err := builder.assignments(tt.patch)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantAssignments, got)
+			if tt.wantErr == nil {
+				assert.Equal(t, tt.wantArgs, builder.args)
+			}
+		})
+	}
+}
+
+func Test_TransactionPatchRequest_RemovesCategory(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "should remove the category with 0", body: `{"categoryID": 0}`, want: true},
+		{name: "should keep the category without an ID", body: `{"hidden": true}`, want: false},
+		{name: "should replace the category", body: `{"categoryID": 3}`, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch TransactionPatchRequest
+			assert.NoError(t, json.Unmarshal([]byte(tt.body), &patch))
+			assert.Equal(t, tt.want, patch.RemovesCategory())
+		})
+	}
+}
+
+func Test_Service_BulkPatch_requiresNarrowingFilter(t *testing.T) {
+	// the filter is checked before the database is used
+	service := &Service{}
+	_, err := service.BulkPatch(audit.Actor{Type: audit.ActorUser}, Filter{Text: " "}, TransactionPatchRequest{Hidden: utils.NewBool(true)}, false)
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+}
@@ -0,0 +1,225 @@
+package transaction
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"docqube.de/bookkeeper/pkg/database"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// SortField is the field transaction lists are sorted by.
+type SortField string
+
+const (
+	SortFieldDate      SortField = "date"
+	SortFieldAmount    SortField = "amount"
+	SortFieldRecipient SortField = "recipient"
+)
+
+func ParseSortField(value string) (SortField, error) {
+	switch field := SortField(value); field {
+	case SortFieldDate, SortFieldAmount, SortFieldRecipient:
+		return field, nil
+	}
+	return "", fmt.Errorf("%w: unknown sort field %s", ErrInvalidFilter, value)
+}
+
+func ParseOrderByDirection(value string) (OrderByDirection, error) {
+	switch direction := OrderByDirection(strings.ToUpper(value)); direction {
+	case OrderByDirectionAsc, OrderByDirectionDesc:
+		return direction, nil
+	}
+	return "", fmt.Errorf("%w: unknown direction %s", ErrInvalidFilter, value)
+}
+
+// Filter selects transactions, all set conditions have to match. The zero filter selects all transactions.
+type Filter struct {
+	// IDs selects transactions by their ID.
+	IDs       []int64
+	From      *time.Time
+	To        *time.Time
+	MinAmount *float64
+	MaxAmount *float64
+	// CategoryIDs and Uncategorized select transactions of one of the categories or without a category.
+	CategoryIDs   []int64
+	Uncategorized bool
+	// Classified selects transactions of categories, which are not archived.
+	Classified bool
+	// Hidden selects hidden or visible transactions, if set.
+	Hidden     *bool
+	AccountIDs []int64
+	// Tags selects transactions having all of the tags.
+	Tags []string
+	// Text is searched in the recipient, booking text, purpose, note and tags.
+	Text string
+}
+
+// Narrows returns true, if the filter selects a subset of all transactions instead of all of them.
+func (f Filter) Narrows() bool {
+	return len(f.IDs) > 0 ||
+		f.From != nil ||
+		f.To != nil ||
+		f.MinAmount != nil ||
+		f.MaxAmount != nil ||
+		len(f.CategoryIDs) > 0 ||
+		f.Uncategorized ||
+		f.Classified ||
+		f.Hidden != nil ||
+		len(f.AccountIDs) > 0 ||
+		len(NormalizeTags(f.Tags)) > 0 ||
+		strings.TrimSpace(f.Text) != ""
+}
+
+// Sort orders transactions by the field in the direction. Ties are ordered by the ID in the
+// opposite direction, as statements list the newest transaction of a day first.
+type Sort struct {
+	Field     SortField
+	Direction OrderByDirection
+}
+
+// Page selects at most limit transactions following the cursor. A limit of zero selects all transactions.
+type Page struct {
+	Cursor string
+	Limit  int
+}
+
+// Chronological sorts transactions from the oldest to the newest.
+var Chronological = Sort{Field: SortFieldDate, Direction: OrderByDirectionAsc}
+
+// cursor points to the last transaction of a page using its sort value.
+type cursor struct {
+	Field     SortField        `json:"f"`
+	Direction OrderByDirection `json:"d"`
+	Value     string           `json:"v"`
+	ID        int64            `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, sort Sort) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	var c cursor
+	err = json.Unmarshal(data, &c)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidCursor, err)
+	}
+	if c.Field != sort.Field || c.Direction != sort.Direction {
+		return nil, fmt.Errorf("%w: cursor of another sort order", ErrInvalidCursor)
+	}
+	return &c, nil
+}
+
+// queryBuilder collects the conditions of a query and their arguments.
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// arg adds the argument and returns its placeholder.
+func (b *queryBuilder) arg(value any) string {
+	b.args = append(b.args, value)
+	return fmt.Sprintf("$%d", len(b.args))
+}
+
+func (b *queryBuilder) where(format string, values ...any) {
+	b.conditions = append(b.conditions, fmt.Sprintf(format, values...))
+}
+
+// condition returns the conjunction of all conditions.
+func (b *queryBuilder) condition() string {
+	if len(b.conditions) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(b.conditions, "\n\t\t\tAND ")
+}
+
+// filter adds the conditions of the filter.
+func (b *queryBuilder) filter(f Filter) {
+	if len(f.IDs) > 0 {
+		b.where("t.id = ANY(%s)", b.arg(pq.Array(f.IDs)))
+	}
+	if f.From != nil {
+		b.where("t.booking_date >= %s", b.arg(database.NormalizeTime(*f.From)))
+	}
+	if f.To != nil {
+		b.where("t.booking_date <= %s", b.arg(database.NormalizeTime(*f.To)))
+	}
+	if f.MinAmount != nil {
+		b.where("t.amount >= %s", b.arg(*f.MinAmount))
+	}
+	if f.MaxAmount != nil {
+		b.where("t.amount <= %s", b.arg(*f.MaxAmount))
+	}
+	switch {
+	case len(f.CategoryIDs) > 0 && f.Uncategorized:
+		b.where("(t.category_id = ANY(%s) OR t.category_id IS NULL)", b.arg(pq.Array(f.CategoryIDs)))
+	case len(f.CategoryIDs) > 0:
+		b.where("t.category_id = ANY(%s)", b.arg(pq.Array(f.CategoryIDs)))
+	case f.Uncategorized:
+		b.where("t.category_id IS NULL")
+	}
+	if f.Classified {
+		b.where("c.archived = false")
+	}
+	if f.Hidden != nil {
+		b.where("t.hidden = %s", b.arg(*f.Hidden))
+	}
+	if len(f.AccountIDs) > 0 {
+		b.where("t.account_id = ANY(%s)", b.arg(pq.Array(f.AccountIDs)))
+	}
+	if len(f.Tags) > 0 {
+		b.where("t.tags @> %s", b.arg(pq.Array(NormalizeTags(f.Tags))))
+	}
+	if text := strings.TrimSpace(f.Text); text != "" {
+		b.where("%s ILIKE %s", searchText, b.arg(likePattern(text)))
+	}
+}
+
+// sortExpression returns the expression sorted by and the type its cursor value is cast to.
+func sortExpression(field SortField) (string, string) {
+	switch field {
+	case SortFieldAmount:
+		return "t.amount", "::float8"
+	case SortFieldRecipient:
+		return "LOWER(COALESCE(t.recipient, ''))", "::text"
+	}
+	return "t.booking_date", "::date"
+}
+
+// orderBy returns the order by clause of the sort.
+func orderBy(sort Sort) string {
+	expression, _ := sortExpression(sort.Field)
+	idDirection := OrderByDirectionDesc
+	if sort.Direction == OrderByDirectionDesc {
+		idDirection = OrderByDirectionAsc
+	}
+	return fmt.Sprintf("%s %s, t.id %s", expression, sort.Direction, idDirection)
+}
+
+// after adds the condition selecting the transactions following the cursor in the sort order.
+func (b *queryBuilder) after(c cursor) {
+	expression, cast := sortExpression(c.Field)
+	value := b.arg(c.Value) + cast
+	id := b.arg(c.ID)
+	if c.Direction == OrderByDirectionDesc {
+		b.where("(%[1]s < %[2]s OR (%[1]s = %[2]s AND t.id > %[3]s))", expression, value, id)
+		return
+	}
+	b.where("(%[1]s > %[2]s OR (%[1]s = %[2]s AND t.id < %[3]s))", expression, value, id)
+}
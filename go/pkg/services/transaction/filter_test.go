@@ -0,0 +1,156 @@
+package transaction
+
+import (
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_queryBuilder_filter(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        Filter
+		wantCondition string
+		wantArgs      []any
+	}{
+		{
+			name:          "should select all transactions without filter",
+			filter:        Filter{},
+			wantCondition: "TRUE",
+			wantArgs:      nil,
+		},
+		{
+			name: "should combine categories with uncategorized transactions",
+			filter: Filter{
+				CategoryIDs:   []int64{1, 2},
+				Uncategorized: true,
+				Hidden:        utils.NewBool(false),
+			},
+			wantCondition: "(t.category_id = ANY($1) OR t.category_id IS NULL)\n\t\t\tAND t.hidden = $2",
+			wantArgs:      []any{pq.Array([]int64{1, 2}), false},
+		},
+		{
+			name: "should filter amounts, accounts and normalized tags",
+			filter: Filter{
+				MinAmount:  utils.NewFloat64(-100),
+				MaxAmount:  utils.NewFloat64(0),
+				AccountIDs: []int64{3},
+				Tags:       []string{"Trip", "trip"},
+			},
+			wantCondition: "t.amount >= $1\n\t\t\tAND t.amount <= $2\n\t\t\tAND t.account_id = ANY($3)\n\t\t\tAND t.tags @> $4",
+			wantArgs:      []any{-100.0, 0.0, pq.Array([]int64{3}), pq.Array([]string{"trip"})},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var builder queryBuilder
+			builder.filter(tt.filter)
+			assert.Equal(t, tt.wantCondition, builder.condition())
+			assert.Equal(t, tt.wantArgs, builder.args)
+		})
+	}
+}
+
+func Test_decodeCursor(t *testing.T) {
+	sort := Sort{Field: SortFieldAmount, Direction: OrderByDirectionDesc}
+	encoded := encodeCursor(cursor{Field: sort.Field, Direction: sort.Direction, Value: "-12.5", ID: 42})
+
+	tests := []struct {
+		name    string
+		value   string
+		sort    Sort
+		want    *cursor
+		wantErr error
+	}{
+		{
+			name:  "should decode an encoded cursor",
+			value: encoded,
+			sort:  sort,
+			want:  &cursor{Field: sort.Field, Direction: sort.Direction, Value: "-12.5", ID: 42},
+		},
+		{
+			name:    "should reject a cursor of another sort order",
+			value:   encoded,
+			sort:    Chronological,
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "should reject an invalid cursor",
+			value:   "not a cursor",
+			sort:    sort,
+			wantErr: ErrInvalidCursor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.value, tt.sort)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_orderBy(t *testing.T) {
+	tests := []struct {
+		name string
+		sort Sort
+		want string
+	}{
+		{
+			name: "should sort chronologically",
+			sort: Chronological,
+			want: "t.booking_date ASC, t.id DESC",
+		},
+		{
+			name: "should sort by amount descending",
+			sort: Sort{Field: SortFieldAmount, Direction: OrderByDirectionDesc},
+			want: "t.amount DESC, t.id ASC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, orderBy(tt.sort))
+		})
+	}
+}
+
+func Test_Filter_Narrows(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{
+			name:   "should not narrow without filter",
+			filter: Filter{},
+			want:   false,
+		},
+		{
+			name:   "should not narrow with empty text and tags",
+			filter: Filter{Text: "  ", Tags: []string{" "}, CategoryIDs: []int64{}},
+			want:   false,
+		},
+		{
+			name:   "should narrow by IDs",
+			filter: Filter{IDs: []int64{1}},
+			want:   true,
+		},
+		{
+			name:   "should narrow by visibility",
+			filter: Filter{Hidden: utils.NewBool(false)},
+			want:   true,
+		},
+		{
+			name:   "should narrow by text",
+			filter: Filter{Text: "rewe"},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.filter.Narrows())
+		})
+	}
+}
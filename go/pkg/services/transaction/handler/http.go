@@ -2,10 +2,18 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"docqube.de/bookkeeper/pkg/services/account"
+	"docqube.de/bookkeeper/pkg/services/audit"
+	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/interval"
+	"docqube.de/bookkeeper/pkg/services/lock"
 	"docqube.de/bookkeeper/pkg/services/transaction"
 	"docqube.de/bookkeeper/pkg/services/transaction/csv"
 	"docqube.de/bookkeeper/pkg/utils"
@@ -13,23 +21,34 @@ import (
 )
 
 type Handler struct {
-	Service *transaction.Service
+	Service         *transaction.Service
+	AccountService  *account.Service
+	IntervalService *interval.Service
 }
 
 func NewHandler(router *gin.RouterGroup, db *sql.DB) *Handler {
 	handler := &Handler{
-		Service: transaction.NewService(db),
+		Service:         transaction.NewService(db),
+		AccountService:  account.NewService(db),
+		IntervalService: interval.NewService(db),
 	}
 
 	transactionsAPI := router.Group("/transactions")
 	transactionsAPI.POST("/csv", handler.ImportCSV)
 	transactionsAPI.GET("/unclassified", handler.ListUnclassified)
+	transactionsAPI.GET("/search", handler.Search)
 	transactionsAPI.GET("/hidden", handler.ListHidden)
+	transactionsAPI.POST("/recategorize", handler.Recategorize)
+	transactionsAPI.POST("/sepa", handler.ExtractSEPAFields)
 	transactionsAPI.GET("", handler.List)
+	transactionsAPI.POST("", handler.Create)
+	transactionsAPI.PATCH("", handler.BulkPatch)
 
 	transactionAPI := router.Group("/transaction")
 	transactionAPI.GET("/:id", handler.Get)
 	transactionAPI.PATCH("/:id", handler.Patch)
+	transactionAPI.PUT("/:id", handler.Update)
+	transactionAPI.DELETE("/:id", handler.Delete)
 
 	return handler
 }
@@ -53,92 +72,450 @@ func (h *Handler) ImportCSV(c *gin.Context) {
 		return
 	}
 
+	// statements are imported into the default account, if no account is passed
+	var importAccount *account.Account
+	if rawAccountID := c.PostForm("account_id"); rawAccountID != "" {
+		var accountID int64
+		accountID, err = strconv.ParseInt(rawAccountID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		importAccount, err = h.AccountService.Get(accountID)
+	} else {
+		importAccount, err = h.AccountService.Default()
+	}
+	if err != nil {
+		if errors.Is(err, account.ErrAccountNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range transactions {
+		transactions[i].AccountID = importAccount.ID
+	}
+
 	err = h.Service.CategorizeAndImport(transactions)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// new incomes may move the start of the stored fiscal months
+	if len(transactions) > 0 {
+		from, to := transactions[0].BookingDate, transactions[0].BookingDate
+		for _, t := range transactions {
+			if t.BookingDate.Before(from) {
+				from = t.BookingDate
+			}
+			if t.BookingDate.After(to) {
+				to = t.BookingDate
+			}
+		}
+		err = h.IntervalService.InvalidateFiscalMonths(from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusCreated, gin.H{})
 }
 
-func (h *Handler) List(c *gin.Context) {
-	from, err := time.Parse(time.DateOnly, c.Query("from"))
+// Create creates a manual transaction, e.g. cash spending or a reimbursement.
+func (h *Handler) Create(c *gin.Context) {
+	var createRequest transaction.TransactionCreateRequest
+	err := c.BindJSON(&createRequest)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	to, err := time.Parse(time.DateOnly, c.Query("to"))
+
+	var transactionAccount *account.Account
+	if createRequest.AccountID != nil {
+		transactionAccount, err = h.AccountService.Get(*createRequest.AccountID)
+	} else {
+		transactionAccount, err = h.AccountService.Default()
+	}
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	t, err := h.Service.CreateManual(audit.ActorFromRequest(c.Request), transactionAccount.ID, createRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	// a manual income may move the start of the stored fiscal months
+	err = h.IntervalService.InvalidateFiscalMonths(t.BookingDate, t.BookingDate)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// Update replaces the descriptive fields of a manual transaction.
+func (h *Handler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var updateRequest transaction.TransactionRequest
+	err = c.BindJSON(&updateRequest)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	current, err := h.Service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	t, err := h.Service.Update(audit.ActorFromRequest(c.Request), id, updateRequest)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	from, to := current.BookingDate, t.BookingDate
+	if to.Before(from) {
+		from, to = to, from
+	}
+	err = h.IntervalService.InvalidateFiscalMonths(from, to)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// Delete deletes a manual transaction.
+func (h *Handler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	rawCategory := c.Query("category")
-	if rawCategory != "" {
-		categoryID, err := strconv.ParseInt(rawCategory, 10, 64)
+	t, err := h.Service.Get(id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.Service.Delete(audit.ActorFromRequest(c.Request), id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	err = h.IntervalService.InvalidateFiscalMonths(t.BookingDate, t.BookingDate)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// defaultSearchLimit and maxSearchLimit limit the number of transactions returned by a search.
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// Search returns the transactions matching the query q ranked by their relevance. The optional
+// period or from and to restrict the search to the transactions booked within.
+func (h *Handler) Search(c *gin.Context) {
+	query, err := transaction.ParseSearchQuery(c.Query("q"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	if c.Query("period") != "" || c.Query("from") != "" || c.Query("to") != "" {
+		var from, to time.Time
+		from, to, err = h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			handleError(c, err)
 			return
 		}
+		query.Restrict(from, to)
+	}
 
-		transactions, err := h.Service.ListByCategoryID(from, to, categoryID, transaction.OrderByDirectionAsc)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	limit := defaultSearchLimit
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 || limit > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)})
 			return
 		}
-		c.JSON(http.StatusOK, transactions)
+	}
+
+	result, err := h.Service.Search(*query, limit)
+	if err != nil {
+		handleError(c, err)
 		return
 	}
 
-	transactions, err := h.Service.List(from, to, transaction.OrderByDirectionAsc)
+	c.JSON(http.StatusOK, result)
+}
+
+// List returns the transactions matching the filter of the query parameters. All filters are
+// optional and can be combined:
+//   - period or from and to select the booking dates
+//   - min_amount and max_amount select the amount range
+//   - category selects one of the categories, passed repeatedly or comma separated
+//   - uncategorized selects transactions without a category, in addition to the categories
+//   - hidden selects hidden or visible transactions, by default both are listed unless
+//     filtered by category, then hidden transactions are excluded
+//   - account and tag select accounts and required tags, passed repeatedly or comma separated
+//   - text searches the recipient, booking text, purpose, note and tags
+//
+// The transactions are sorted by sort (date, amount or recipient) in the order (asc or desc).
+// If a limit is passed, the next cursor of the response selects the following page.
+func (h *Handler) List(c *gin.Context) {
+	filter, err := h.parseFilter(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, transactions)
+	h.list(c, filter)
 }
 
+// ListUnclassified lists the visible transactions without a category.
 func (h *Handler) ListUnclassified(c *gin.Context) {
-	from, err := time.Parse(time.DateOnly, c.Query("from"))
+	filter, err := h.parseFilter(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
+	filter.Uncategorized = true
+	filter.Hidden = utils.NewBool(false)
+	h.list(c, filter)
+}
 
-	to, err := time.Parse(time.DateOnly, c.Query("to"))
+// ListHidden lists the hidden transactions.
+func (h *Handler) ListHidden(c *gin.Context) {
+	filter, err := h.parseFilter(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleError(c, err)
+		return
+	}
+	filter.Hidden = utils.NewBool(true)
+	h.list(c, filter)
+}
+
+// list returns a page of the filtered transactions using the sort and page of the query parameters.
+func (h *Handler) list(c *gin.Context, filter transaction.Filter) {
+	sort := transaction.Chronological
+	var err error
+	if rawSort := c.Query("sort"); rawSort != "" {
+		sort.Field, err = transaction.ParseSortField(rawSort)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+	if rawOrder := c.Query("order"); rawOrder != "" {
+		sort.Direction, err = transaction.ParseOrderByDirection(rawOrder)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
+	page := transaction.Page{
+		Cursor: c.Query("cursor"),
+	}
+	if rawLimit := c.Query("limit"); rawLimit != "" {
+		page.Limit, err = strconv.Atoi(rawLimit)
+		if err != nil || page.Limit <= 0 || page.Limit > maxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxListLimit)})
+			return
+		}
+	}
+
+	transactions, err := h.Service.List(filter, sort, page)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, transactions)
+}
+
+// maxListLimit is the maximum number of transactions of a page.
+const maxListLimit = 1000
+
+func (h *Handler) parseFilter(c *gin.Context) (transaction.Filter, error) {
+	var filter transaction.Filter
+	if c.Query("period") != "" || c.Query("from") != "" || c.Query("to") != "" {
+		from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+		if err != nil {
+			return filter, err
+		}
+		filter.From = &from
+		filter.To = &to
+	}
+
+	var err error
+	filter.MinAmount, err = parseAmount(c.Query("min_amount"))
+	if err != nil {
+		return filter, err
+	}
+	filter.MaxAmount, err = parseAmount(c.Query("max_amount"))
+	if err != nil {
+		return filter, err
+	}
+
+	filter.CategoryIDs, err = parseIDs(c.QueryArray("category"))
+	if err != nil {
+		return filter, err
+	}
+	filter.AccountIDs, err = parseIDs(c.QueryArray("account"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Uncategorized = c.Query("uncategorized") == "true"
+
+	switch rawHidden := c.Query("hidden"); rawHidden {
+	case "true", "false":
+		filter.Hidden = utils.NewBool(rawHidden == "true")
+	case "":
+		if len(filter.CategoryIDs) > 0 {
+			filter.Hidden = utils.NewBool(false)
+		}
+	default:
+		return filter, fmt.Errorf("%w: hidden must be true or false", transaction.ErrInvalidFilter)
+	}
+
+	filter.Tags = splitValues(c.QueryArray("tag"))
+	filter.Text = c.Query("text")
+	return filter, nil
+}
+
+func parseAmount(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid amount %s", transaction.ErrInvalidFilter, value)
+	}
+	return &amount, nil
+}
+
+func parseIDs(values []string) ([]int64, error) {
+	ids := make([]int64, 0)
+	for _, value := range splitValues(values) {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid ID %s", transaction.ErrInvalidFilter, value)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// splitValues splits comma separated query parameters passed once or repeatedly.
+func splitValues(values []string) []string {
+	result := make([]string, 0)
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+func (h *Handler) Recategorize(c *gin.Context) {
+	from, to, err := h.IntervalService.Range(c.Query("period"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		handleError(c, err)
 		return
 	}
 
-	transactions, err := h.Service.ListUnclassified(from, to, transaction.OrderByDirectionAsc)
+	all := false
+	if rawAll := c.Query("all"); rawAll != "" {
+		all, err = strconv.ParseBool(rawAll)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := h.Service.Recategorize(from, to, all)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, transactions)
+
+	if result.Changed > 0 {
+		// categorized incomes may move the start of the stored fiscal months
+		err = h.IntervalService.InvalidateFiscalMonths(from, to)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, result)
 }
 
-func (h *Handler) ListHidden(c *gin.Context) {
-	from, err := time.Parse(time.DateOnly, c.Query("from"))
+func (h *Handler) ExtractSEPAFields(c *gin.Context) {
+	result, err := h.Service.ExtractSEPAFields(audit.ActorFromRequest(c.Request))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	c.JSON(http.StatusOK, result)
+}
 
-	to, err := time.Parse(time.DateOnly, c.Query("to"))
+// BulkPatch patches the transactions selected by the IDs of the request and the filter of the
+// query parameters, which are the same as for listing transactions. Requests selecting all
+// transactions, e.g. with only sort or empty filter parameters, are rejected.
+func (h *Handler) BulkPatch(c *gin.Context) {
+	var request transaction.BulkPatchRequest
+	err := c.BindJSON(&request)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	filter, err := h.parseFilter(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	filter.IDs = request.IDs
 
-	transactions, err := h.Service.ListHidden(from, to, transaction.OrderByDirectionAsc)
+	result, err := h.Service.BulkPatch(audit.ActorFromRequest(c.Request), filter, request.TransactionPatchRequest, request.DryRun)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, transactions)
+
+	if !result.DryRun && result.Affected > 0 && (request.CategoryID != nil || request.Hidden != nil) {
+		// categorized or hidden incomes may move the start of the stored fiscal months
+		err = h.IntervalService.InvalidateAllFiscalMonths()
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
 }
 
 func (h *Handler) Patch(c *gin.Context) {
@@ -155,25 +532,40 @@ func (h *Handler) Patch(c *gin.Context) {
 		return
 	}
 
+	actor := audit.ActorFromRequest(c.Request)
 	if patchRequest.CategoryID != nil {
-		if patchRequest.CategoryID == utils.NewInt64(0) {
-			err = h.Service.Uncategorize(id)
+		if patchRequest.RemovesCategory() {
+			err = h.Service.Uncategorize(actor, id)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				handleError(c, err)
 				return
 			}
 		} else {
-			err = h.Service.Categorize(id, *patchRequest.CategoryID)
+			err = h.Service.Categorize(actor, id, *patchRequest.CategoryID)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				handleError(c, err)
 				return
 			}
 		}
 	}
 	if patchRequest.Hidden != nil {
-		err = h.Service.Hide(id, *patchRequest.Hidden)
+		err = h.Service.Hide(actor, id, *patchRequest.Hidden)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			handleError(c, err)
+			return
+		}
+	}
+	if patchRequest.Note != nil {
+		err = h.Service.SetNote(actor, id, *patchRequest.Note)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+	if patchRequest.Tags != nil {
+		err = h.Service.SetTags(actor, id, *patchRequest.Tags)
+		if err != nil {
+			handleError(c, err)
 			return
 		}
 	}
@@ -184,6 +576,15 @@ func (h *Handler) Patch(c *gin.Context) {
 		return
 	}
 
+	if patchRequest.CategoryID != nil || patchRequest.Hidden != nil {
+		// a categorized or hidden income may move the start of the stored fiscal months
+		err = h.IntervalService.InvalidateFiscalMonths(transaction.BookingDate, transaction.BookingDate)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, transaction)
 }
 
@@ -194,11 +595,34 @@ func (h *Handler) Get(c *gin.Context) {
 		return
 	}
 
-	transaction, err := h.Service.Get(id)
+	t, err := h.Service.Get(id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transaction)
+	c.JSON(http.StatusOK, t)
+}
+
+func handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, transaction.ErrTransactionNotFound),
+		errors.Is(err, account.ErrAccountNotFound),
+		errors.Is(err, category.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, transaction.ErrInvalidTransaction),
+		errors.Is(err, transaction.ErrInvalidSearchQuery),
+		errors.Is(err, transaction.ErrInvalidFilter),
+		errors.Is(err, transaction.ErrInvalidCursor):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, transaction.ErrTransactionImmutable),
+		errors.Is(err, transaction.ErrTransactionInUse):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, interval.ErrInvalidPeriod):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, lock.ErrPeriodLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/services/transaction"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Handler_BulkPatch_requiresNarrowingFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "should reject a request without filter", query: ""},
+		{name: "should reject a request only sorting", query: "?sort=date"},
+		{name: "should reject a request only limiting", query: "?limit=5"},
+		{name: "should reject an empty text", query: "?text="},
+		{name: "should reject a request not selecting uncategorized transactions", query: "?uncategorized=false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the filter is checked before the database is used
+			handler := &Handler{Service: transaction.NewService(nil)}
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodPatch, "/transactions"+tt.query, strings.NewReader(`{"hidden": true}`))
+
+			handler.BulkPatch(c)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.Contains(t, recorder.Body.String(), "either IDs or a filter are required")
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSearchQuery = errors.New("invalid search query")
+
+// AmountCondition compares the amount of a transaction with the value using the operator,
+// which is one of <, <=, >, >= and =.
+type AmountCondition struct {
+	Operator string
+	Value    float64
+}
+
+// SearchQuery is a parsed search query. Text fields match case-insensitive substrings, all
+// conditions have to match, except for the categories of which one has to match.
+type SearchQuery struct {
+	// Terms are searched in the recipient, booking text, purpose, note and tags.
+	Terms        []string
+	Amounts      []AmountCondition
+	Categories   []string
+	Recipients   []string
+	BookingTexts []string
+	Purposes     []string
+	Notes        []string
+	Tags         []string
+	From         *time.Time
+	To           *time.Time
+}
+
+type SearchHit struct {
+	Transaction
+	// Rank is the relevance of the transaction for the terms of the query.
+	Rank float64 `json:"rank"`
+}
+
+type SearchResult struct {
+	Items []SearchHit `json:"items"`
+	Total int64       `json:"total"`
+}
+
+// uncategorized is the category value matching transactions without a category.
+const uncategorized = "none"
+
+// ParseSearchQuery parses a query like `amount:<-100 category:Groceries recipient:"rewe" plumber`.
+// Supported fields are amount, category, recipient, text (the booking text), purpose, note, tag,
+// from, to and date. Amounts are compared with <, <=, >, >= and = or match a range like 10..20,
+// dates use the format 2006-01-02 and date accepts a single day or a range. Values containing
+// spaces are quoted. Words with an unknown field are searched as terms.
+func ParseSearchQuery(raw string) (*SearchQuery, error) {
+	tokens, err := tokenize(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var query SearchQuery
+	for _, token := range tokens {
+		if token.value == "" {
+			continue
+		}
+
+		switch token.field {
+		case "":
+			query.Terms = append(query.Terms, token.value)
+		case "amount":
+			conditions, err := parseAmount(token.value)
+			if err != nil {
+				return nil, err
+			}
+			query.Amounts = append(query.Amounts, conditions...)
+		case "category":
+			query.Categories = append(query.Categories, token.value)
+		case "recipient":
+			query.Recipients = append(query.Recipients, token.value)
+		case "text":
+			query.BookingTexts = append(query.BookingTexts, token.value)
+		case "purpose":
+			query.Purposes = append(query.Purposes, token.value)
+		case "note":
+			query.Notes = append(query.Notes, token.value)
+		case "tag":
+			query.Tags = append(query.Tags, strings.ToLower(token.value))
+		case "from", "to", "date":
+			from, to, err := parseDateRange(token.value)
+			if err != nil {
+				return nil, err
+			}
+			if token.field != "to" {
+				query.From = &from
+			}
+			if token.field != "from" {
+				query.To = &to
+			}
+		default:
+			query.Terms = append(query.Terms, token.field+":"+token.value)
+		}
+	}
+
+	return &query, nil
+}
+
+// Restrict limits the query to transactions booked between from and to.
+func (q *SearchQuery) Restrict(from, to time.Time) {
+	if q.From == nil || q.From.Before(from) {
+		q.From = &from
+	}
+	if q.To == nil || q.To.After(to) {
+		q.To = &to
+	}
+}
+
+type token struct {
+	field string
+	value string
+}
+
+// tokenize splits the query at whitespace outside of quotes into terms and field:value pairs.
+func tokenize(raw string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(raw)
+	for i := 0; i < len(runes); {
+		if runes[i] == ' ' || runes[i] == '\t' || runes[i] == '\n' {
+			i++
+			continue
+		}
+
+		var t token
+		start := i
+		for i < len(runes) && runes[i] != ' ' && runes[i] != '\t' && runes[i] != '\n' && runes[i] != '"' {
+			if runes[i] == ':' && t.field == "" && i > start {
+				t.field = strings.ToLower(string(runes[start:i]))
+				start = i + 1
+			}
+			i++
+		}
+
+		if i < len(runes) && runes[i] == '"' {
+			if i != start {
+				return nil, fmt.Errorf("%w: unexpected quote at position %d", ErrInvalidSearchQuery, i)
+			}
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("%w: unterminated quote", ErrInvalidSearchQuery)
+			}
+			t.value = string(runes[i+1 : end])
+			i = end + 1
+		} else {
+			t.value = string(runes[start:i])
+		}
+
+		t.value = strings.TrimSpace(t.value)
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+func parseAmount(value string) ([]AmountCondition, error) {
+	if lower, upper, ok := strings.Cut(value, ".."); ok {
+		min, err := parseNumber(lower)
+		if err != nil {
+			return nil, err
+		}
+		max, err := parseNumber(upper)
+		if err != nil {
+			return nil, err
+		}
+		return []AmountCondition{{Operator: ">=", Value: min}, {Operator: "<=", Value: max}}, nil
+	}
+
+	operator := "="
+	for _, prefix := range []string{"<=", ">=", "<", ">", "="} {
+		if strings.HasPrefix(value, prefix) {
+			operator = prefix
+			value = strings.TrimPrefix(value, prefix)
+			break
+		}
+	}
+	number, err := parseNumber(value)
+	if err != nil {
+		return nil, err
+	}
+	return []AmountCondition{{Operator: operator, Value: number}}, nil
+}
+
+// parseNumber parses amounts with a decimal point or a decimal comma.
+func parseNumber(value string) (float64, error) {
+	number, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64)
+	// ParseFloat accepts NaN and infinities, which can't be compared with amounts
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, fmt.Errorf("%w: invalid amount %q", ErrInvalidSearchQuery, value)
+	}
+	return number, nil
+}
+
+func parseDateRange(value string) (time.Time, time.Time, error) {
+	rawFrom, rawTo, ok := strings.Cut(value, "..")
+	if !ok {
+		rawTo = rawFrom
+	}
+	from, err := time.Parse(time.DateOnly, rawFrom)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date %q", ErrInvalidSearchQuery, rawFrom)
+	}
+	to, err := time.Parse(time.DateOnly, rawTo)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid date %q", ErrInvalidSearchQuery, rawTo)
+	}
+	return from, to, nil
+}
+
+// likePattern returns a pattern matching the value as substring, so wildcards in the value are escaped.
+func likePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
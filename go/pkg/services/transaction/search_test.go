@@ -0,0 +1,125 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseSearchQuery(t *testing.T) {
+	date := func(year int, month time.Month, day int) *time.Time {
+		value := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &value
+	}
+
+	tests := []struct {
+		name    string
+		raw     string
+		want    *SearchQuery
+		wantErr error
+	}{
+		{
+			name: "should parse terms and fields",
+			raw:  `amount:<-100 category:Groceries recipient:"rewe markt" plumber`,
+			want: &SearchQuery{
+				Terms:      []string{"plumber"},
+				Amounts:    []AmountCondition{{Operator: "<", Value: -100}},
+				Categories: []string{"Groceries"},
+				Recipients: []string{"rewe markt"},
+			},
+		},
+		{
+			name: "should parse quoted phrases and amount ranges",
+			raw:  `"last year" amount:-50,5..-10 tag:Vacation`,
+			want: &SearchQuery{
+				Terms:   []string{"last year"},
+				Amounts: []AmountCondition{{Operator: ">=", Value: -50.5}, {Operator: "<=", Value: -10}},
+				Tags:    []string{"vacation"},
+			},
+		},
+		{
+			name: "should parse date ranges",
+			raw:  `date:2024-01-01..2024-03-31 text:lastschrift note:receipt purpose:invoice`,
+			want: &SearchQuery{
+				BookingTexts: []string{"lastschrift"},
+				Notes:        []string{"receipt"},
+				Purposes:     []string{"invoice"},
+				From:         date(2024, 1, 1),
+				To:           date(2024, 3, 31),
+			},
+		},
+		{
+			name: "should parse from and to",
+			raw:  `from:2024-02-01 to:2024-02-29 amount:>=20`,
+			want: &SearchQuery{
+				Amounts: []AmountCondition{{Operator: ">=", Value: 20}},
+				From:    date(2024, 2, 1),
+				To:      date(2024, 2, 29),
+			},
+		},
+		{
+			name: "should search unknown fields as terms",
+			raw:  `Ref:4711`,
+			want: &SearchQuery{Terms: []string{"ref:4711"}},
+		},
+		{
+			name:    "should reject invalid amounts",
+			raw:     `amount:<abc`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "should reject NaN",
+			raw:     `amount:NaN`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "should reject infinite amounts",
+			raw:     `amount:-Inf..0`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "should reject amounts out of range",
+			raw:     `amount:>1e400`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "should reject invalid dates",
+			raw:     `from:01.02.2024`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "should reject unterminated quotes",
+			raw:     `recipient:"rewe`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(tt.raw)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_SearchQuery_Restrict(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	queryFrom := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	query := SearchQuery{From: &queryFrom}
+	query.Restrict(from, to)
+
+	assert.Equal(t, queryFrom, *query.From)
+	assert.Equal(t, to, *query.To)
+}
+
+func Test_likePattern(t *testing.T) {
+	assert.Equal(t, `%100\%\_sale%`, likePattern("100%_sale"))
+}
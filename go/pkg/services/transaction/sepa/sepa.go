@@ -0,0 +1,190 @@
+package sepa
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Fields are the structured SEPA and card payment fields
+// banks embed into the free text purpose of a transaction.
+type Fields struct {
+	IBAN              *string `json:"iban"`
+	BIC               *string `json:"bic"`
+	CreditorID        *string `json:"creditorID"`
+	MandateReference  *string `json:"mandateReference"`
+	EndToEndReference *string `json:"endToEndReference"`
+	CardNumber        *string `json:"cardNumber"`
+	CardTerminal      *string `json:"cardTerminal"`
+}
+
+var (
+	// taggedFieldRegex matches the SEPA field tags used in structured purposes, e.g.
+	// "EREF+123 MREF+M-42 CRED+DE98ZZZ09999999999 SVWZ+Rent".
+	taggedFieldRegex = regexp.MustCompile(`(EREF|KREF|MREF|CRED|DEBT|SVWZ|ABWA|ABWE|IBAN|BIC)\+`)
+
+	// labeledFieldRegex matches the labeled fields used by e.g. ING, like
+	// "Mandat: M-42 Referenz: 123 Gläubiger-ID: DE98ZZZ09999999999".
+	labeledFieldRegex = regexp.MustCompile(`(?i)(Mandatsreferenz|Mandatsref\.?|Mandat|End-to-End-Ref\.?|End-to-End-Referenz|Kundenreferenz|Referenz|Gl(?:ä|ae)ubiger-ID|Creditor ID|IBAN|BIC):\s*`)
+
+	ibanRegex       = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`)
+	creditorIDRegex = regexp.MustCompile(`\b[A-Z]{2}\d{2}ZZZ[A-Z0-9]{1,28}\b`)
+	bicRegex        = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}(?:[A-Z0-9]{3})?$`)
+
+	// cardPaymentRegex matches card payments like "NR XXXX 1337 MONSCHAU KAUFUMSATZ 01.01 0815123"
+	// or "NR XXXX 0815 MONSCHAU Apple Pay", where the text after the card number is the terminal.
+	cardPaymentRegex = regexp.MustCompile(`(?i)^NR\s+([X\d]{4}\s+\d{4})\s+(.+?)(?:\s+(?:KAUFUMSATZ|Apple Pay|Google Pay|\d{2}\.\d{2}\b).*)?$`)
+)
+
+// Parse extracts all known SEPA and card payment fields from the passed purpose.
+func Parse(purpose *string) Fields {
+	var fields Fields
+	if purpose == nil {
+		return fields
+	}
+	value := strings.TrimSpace(*purpose)
+
+	parseTaggedFields(value, &fields)
+	parseLabeledFields(value, &fields)
+
+	// fall back to bare identifiers anywhere in the purpose
+	if fields.CreditorID == nil {
+		if match := creditorIDRegex.FindString(value); match != "" {
+			fields.CreditorID = &match
+		}
+	}
+	if fields.IBAN == nil {
+		for _, match := range ibanRegex.FindAllString(value, -1) {
+			iban := NormalizeIdentifier(match)
+			if ValidIBAN(iban) {
+				fields.IBAN = &iban
+				break
+			}
+		}
+	}
+
+	if match := cardPaymentRegex.FindStringSubmatch(value); match != nil {
+		cardNumber := strings.Join(strings.Fields(match[1]), " ")
+		terminal := strings.TrimSpace(match[2])
+		fields.CardNumber = &cardNumber
+		fields.CardTerminal = &terminal
+	}
+
+	return fields
+}
+
+func parseTaggedFields(value string, fields *Fields) {
+	for tag, content := range splitFields(value, taggedFieldRegex) {
+		switch tag {
+		case "EREF":
+			if content != "NOTPROVIDED" {
+				fields.EndToEndReference = firstValue(fields.EndToEndReference, content)
+			}
+		case "MREF":
+			fields.MandateReference = firstValue(fields.MandateReference, content)
+		case "CRED":
+			fields.CreditorID = firstValue(fields.CreditorID, NormalizeIdentifier(content))
+		case "IBAN":
+			if iban := NormalizeIdentifier(content); ValidIBAN(iban) {
+				fields.IBAN = firstValue(fields.IBAN, iban)
+			}
+		case "BIC":
+			if bic := NormalizeIdentifier(content); bicRegex.MatchString(bic) {
+				fields.BIC = firstValue(fields.BIC, bic)
+			}
+		}
+	}
+}
+
+func parseLabeledFields(value string, fields *Fields) {
+	for label, content := range splitFields(value, labeledFieldRegex) {
+		// labeled values never contain whitespace, the rest is free text
+		tokens := strings.Fields(content)
+		if len(tokens) == 0 {
+			continue
+		}
+		token := tokens[0]
+
+		switch label := strings.ToLower(label); {
+		case strings.HasPrefix(label, "mandat"):
+			fields.MandateReference = firstValue(fields.MandateReference, token)
+		case strings.HasPrefix(label, "end-to-end"), label == "referenz", label == "kundenreferenz":
+			if token != "NOTPROVIDED" {
+				fields.EndToEndReference = firstValue(fields.EndToEndReference, token)
+			}
+		case strings.HasPrefix(label, "gl"), label == "creditor id":
+			fields.CreditorID = firstValue(fields.CreditorID, NormalizeIdentifier(token))
+		case label == "iban":
+			// IBANs are often printed in groups of four, so the whole content is searched
+			if iban := NormalizeIdentifier(ibanRegex.FindString(strings.ToUpper(content))); ValidIBAN(iban) {
+				fields.IBAN = firstValue(fields.IBAN, iban)
+			}
+		case label == "bic":
+			if bic := NormalizeIdentifier(token); bicRegex.MatchString(bic) {
+				fields.BIC = firstValue(fields.BIC, bic)
+			}
+		}
+	}
+}
+
+// splitFields returns the content following every match of the passed regex up to the
+// next match, keyed by the first submatch. Only the first occurrence of a key is kept.
+func splitFields(value string, regex *regexp.Regexp) map[string]string {
+	fields := map[string]string{}
+
+	matches := regex.FindAllStringSubmatchIndex(value, -1)
+	for i, match := range matches {
+		end := len(value)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+
+		key := value[match[2]:match[3]]
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		fields[key] = strings.TrimSpace(value[match[1]:end])
+	}
+	return fields
+}
+
+func firstValue(current *string, value string) *string {
+	if current != nil || value == "" {
+		return current
+	}
+	return &value
+}
+
+// NormalizeIdentifier removes all whitespace from the passed identifier
+// like an IBAN or creditor ID and converts it to upper case.
+func NormalizeIdentifier(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
+
+// ValidIBAN checks the length and the ISO 13616 check digits of the passed normalized IBAN.
+func ValidIBAN(iban string) bool {
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// move the country code and check digits to the end and
+	// convert all letters to numbers, e.g. A = 10 and Z = 35
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+
+	number, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(number, big.NewInt(97)).Int64() == 1
+}
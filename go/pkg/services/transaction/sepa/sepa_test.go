@@ -0,0 +1,106 @@
+package sepa
+
+import (
+	"testing"
+
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		purpose *string
+		want    Fields
+	}{
+		{
+			name:    "should parse tagged fields",
+			purpose: utils.NewString("EREF+INV-2023-0042 MREF+M-4711 CRED+DE98ZZZ09999999999 SVWZ+Miete Mai IBAN+DE89 3704 0044 0532 0130 00 BIC+COBADEFFXXX"),
+			want: Fields{
+				IBAN:              utils.NewString("DE89370400440532013000"),
+				BIC:               utils.NewString("COBADEFFXXX"),
+				CreditorID:        utils.NewString("DE98ZZZ09999999999"),
+				MandateReference:  utils.NewString("M-4711"),
+				EndToEndReference: utils.NewString("INV-2023-0042"),
+			},
+		},
+		{
+			name:    "should parse labeled fields",
+			purpose: utils.NewString("Beitrag 05/2023 Mandat: M-4711 Referenz: 20230501-42 Gläubiger-ID: DE98ZZZ09999999999"),
+			want: Fields{
+				CreditorID:        utils.NewString("DE98ZZZ09999999999"),
+				MandateReference:  utils.NewString("M-4711"),
+				EndToEndReference: utils.NewString("20230501-42"),
+			},
+		},
+		{
+			name:    "should ignore not provided references",
+			purpose: utils.NewString("EREF+NOTPROVIDED SVWZ+Geschenk"),
+			want:    Fields{},
+		},
+		{
+			name:    "should find bare identifiers",
+			purpose: utils.NewString("Rueckzahlung an DE89 3704 0044 0532 0130 00 glaeubiger DE98ZZZ09999999999"),
+			want: Fields{
+				IBAN:       utils.NewString("DE89370400440532013000"),
+				CreditorID: utils.NewString("DE98ZZZ09999999999"),
+			},
+		},
+		{
+			name:    "should ignore invalid IBANs",
+			purpose: utils.NewString("IBAN: DE12 3456 7890 1234 5678 90"),
+			want:    Fields{},
+		},
+		{
+			name:    "should parse card payment",
+			purpose: utils.NewString("NR XXXX 1234 MONSCHAU KAUFUMSATZ 01.01 123456789"),
+			want: Fields{
+				CardNumber:   utils.NewString("XXXX 1234"),
+				CardTerminal: utils.NewString("MONSCHAU"),
+			},
+		},
+		{
+			name:    "should parse mobile card payment",
+			purpose: utils.NewString("NR XXXX 0815 MONSCHAU Apple Pay"),
+			want: Fields{
+				CardNumber:   utils.NewString("XXXX 0815"),
+				CardTerminal: utils.NewString("MONSCHAU"),
+			},
+		},
+		{
+			name:    "should not parse free text",
+			purpose: utils.NewString("Auto Leasing/VT12345678 05/23 Rate"),
+			want:    Fields{},
+		},
+		{
+			name:    "should handle missing purpose",
+			purpose: nil,
+			want:    Fields{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.purpose))
+		})
+	}
+}
+
+func Test_ValidIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		want bool
+	}{
+		{iban: "DE89370400440532013000", want: true},
+		{iban: "GB82WEST12345698765432", want: true},
+		{iban: "DE12345678901234567890", want: false},
+		{iban: "DE8937040044", want: false},
+		{iban: "DE89-370400440532013000", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.iban, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidIBAN(tt.iban))
+		})
+	}
+}
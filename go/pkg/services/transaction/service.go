@@ -3,71 +3,188 @@ package transaction
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"docqube.de/bookkeeper/pkg/database"
+	"docqube.de/bookkeeper/pkg/services/audit"
 	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/lock"
+	"docqube.de/bookkeeper/pkg/services/payee"
+	"docqube.de/bookkeeper/pkg/services/transaction/sepa"
+	"docqube.de/bookkeeper/pkg/utils"
+	"github.com/lib/pq"
 )
 
 var (
-	ErrTransactionExists = fmt.Errorf("transaction already exists in database")
+	ErrTransactionExists    = fmt.Errorf("transaction already exists in database")
+	ErrTransactionNotFound  = fmt.Errorf("transaction not found")
+	ErrTransactionImmutable = fmt.Errorf("imported transactions can't be edited or deleted")
+	ErrTransactionInUse     = fmt.Errorf("transaction has attachments")
 )
 
 type Service struct {
 	db              *sql.DB
 	categoryService *category.Service
+	payeeService    *payee.Service
+	lockService     *lock.Service
 	categories      []category.Category
+	payees          []payee.Payee
 }
 
 func NewService(db *sql.DB) *Service {
 	return &Service{
 		db:              db,
 		categoryService: category.NewService(db),
+		payeeService:    payee.NewService(db),
+		lockService:     lock.NewService(db),
 		categories:      []category.Category{},
+		payees:          []payee.Payee{},
 	}
 }
 
 func (s *Service) CategorizeAndImport(transactions []Transaction) error {
-	categories, err := s.categoryService.List()
+	categories, err := s.categoryService.List(false)
 	if err != nil {
 		return err
 	}
 	s.categories = categories
 
+	payees, err := s.payeeService.List()
+	if err != nil {
+		return err
+	}
+	s.payees = payees
+
+	hits := category.NewRuleHits()
 	for _, t := range transactions {
-		category, err := s.MatchTransactionCategory(&t)
+		// the payee and the SEPA fields have to be extracted first, as rules may match against them
+		t.SEPA = sepa.Parse(t.Purpose)
+		t.Payee, err = payee.Normalize(s.payees, t.Recipient)
+		if err != nil {
+			return err
+		}
+
+		transactionHits := category.NewRuleHits()
+		category, err := s.matchTransaction(&t, transactionHits)
 		if err != nil {
 			return err
 		}
 		t.Category = category
 
-		_, err = s.Create(t)
+		_, err = s.Create(audit.Import, t)
 		if err != nil {
 			if err == ErrTransactionExists {
 				continue
 			}
 			return err
 		}
+		hits.Merge(transactionHits)
 	}
 
-	return nil
+	return s.categoryService.RecordRuleHits(hits)
 }
 
-func (s *Service) MatchTransactionCategory(transaction *Transaction) (*category.Category, error) {
-	for _, c := range s.categories {
-		matches, err := transaction.MatchesCategory(&c)
+// Recategorize applies the current category rules to all visible transactions booked
+// between from and to. Only unclassified transactions are considered, unless all is set.
+// Transactions not matching any rule or booked within a locked period keep their category.
+func (s *Service) Recategorize(from, to time.Time, all bool) (*RecategorizeResult, error) {
+	categories, err := s.categoryService.List(false)
+	if err != nil {
+		return nil, err
+	}
+	s.categories = categories
+
+	filter := Filter{From: &from, To: &to}
+	if !all {
+		filter.Uncategorized = true
+		filter.Hidden = utils.NewBool(false)
+	}
+	transactions, err := s.List(filter, Chronological, Page{})
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := s.lockService.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var result RecategorizeResult
+	hits := category.NewRuleHits()
+	for _, t := range transactions.Items {
+		if t.Hidden {
+			continue
+		}
+		if lock.Covering(locks, t.BookingDate) != nil {
+			result.Locked++
+			continue
+		}
+		result.Evaluated++
+
+		// the rule statistics are only recorded for changed transactions, as they would
+		// be counted again by every re-categorization otherwise
+		transactionHits := category.NewRuleHits()
+		category, err := s.matchTransaction(&t, transactionHits)
 		if err != nil {
 			return nil, err
 		}
+		if category == nil || (t.Category != nil && t.Category.ID == category.ID) {
+			continue
+		}
 
-		if matches {
-			return &c, nil
+		err = s.Categorize(audit.RuleEngine, t.ID, category.ID)
+		if err != nil {
+			return nil, err
 		}
+		result.Changed++
+		hits.Merge(transactionHits)
+	}
+
+	err = s.categoryService.RecordRuleHits(hits)
+	if err != nil {
+		return nil, err
 	}
-	return nil, nil
+	return &result, nil
+}
+
+func (s *Service) MatchTransactionCategory(transaction *Transaction) (*category.Category, error) {
+	return s.matchTransaction(transaction, nil)
 }
 
-func (s *Service) Create(transaction Transaction) (*Transaction, error) {
+// matchTransaction returns the first category with a rule matching the passed transaction.
+// If rule hits are passed, all remaining rules are evaluated as well to record the ones
+// shadowed by a rule of another category.
+func (s *Service) matchTransaction(transaction *Transaction, hits *category.RuleHits) (*category.Category, error) {
+	var matched *category.Category
+	for _, c := range s.categories {
+		for _, rule := range c.Rules {
+			matches, err := transaction.MatchesRule(&rule)
+			if err != nil {
+				return nil, err
+			}
+			if !matches {
+				continue
+			}
+
+			if matched != nil {
+				if matched.ID != c.ID {
+					hits.Shadow(rule.ID)
+				}
+				continue
+			}
+
+			matched = &c
+			if hits == nil {
+				return matched, nil
+			}
+			hits.Hit(rule.ID, transaction.BookingDate)
+		}
+	}
+	return matched, nil
+}
+
+func (s *Service) Create(actor audit.Actor, transaction Transaction) (*Transaction, error) {
 	exists, err := s.Exists(transaction)
 	if err != nil {
 		return nil, err
@@ -75,22 +192,86 @@ func (s *Service) Create(transaction Transaction) (*Transaction, error) {
 	if exists {
 		return nil, ErrTransactionExists
 	}
+	return s.insert(actor, transaction)
+}
 
+// CreateManual creates a manual transaction in the account. The category is assigned by the
+// category rules, unless one is passed. Manual transactions are never treated as duplicates.
+func (s *Service) CreateManual(actor audit.Actor, accountID int64, request TransactionCreateRequest) (*Transaction, error) {
+	transaction, err := ParseManual(request.TransactionRequest)
+	if err != nil {
+		return nil, err
+	}
+	transaction.AccountID = accountID
+
+	locks, err := s.lockService.List()
+	if err != nil {
+		return nil, err
+	}
+	err = lock.Check(locks, transaction.BookingDate)
+	if err != nil {
+		return nil, err
+	}
+
+	payees, err := s.payeeService.List()
+	if err != nil {
+		return nil, err
+	}
+	transaction.SEPA = sepa.Parse(transaction.Purpose)
+	transaction.Payee, err = payee.Normalize(payees, transaction.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.CategoryID != nil && *request.CategoryID != 0 {
+		transaction.Category, err = s.categoryService.Get(*request.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		s.categories, err = s.categoryService.List(false)
+		if err != nil {
+			return nil, err
+		}
+		transaction.Category, err = s.matchTransaction(transaction, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := s.insert(actor, *transaction)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(created.ID)
+}
+
+func (s *Service) insert(actor audit.Actor, transaction Transaction) (*Transaction, error) {
 	var (
 		id         int64
 		categoryID *int64
+		payeeID    *int64
 	)
 
 	if transaction.Category != nil {
 		categoryID = &transaction.Category.ID
 	}
+	if transaction.Payee != nil {
+		payeeID = &transaction.Payee.ID
+	}
 
 	hash, err := transaction.Hash()
 	if err != nil {
 		return nil, err
 	}
 
-	err = s.db.QueryRow(`
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
 		INSERT INTO transactions (
 			booking_date,
 			valuta_date,
@@ -100,7 +281,17 @@ func (s *Service) Create(transaction Transaction) (*Transaction, error) {
 			balance,
 			amount,
 			category_id,
-			hash
+			payee_id,
+			iban,
+			bic,
+			creditor_id,
+			mandate_reference,
+			end_to_end_reference,
+			card_number,
+			card_terminal,
+			hash,
+			account_id,
+			manual
 		) VALUES (
 			$1,
 			$2,
@@ -110,7 +301,17 @@ func (s *Service) Create(transaction Transaction) (*Transaction, error) {
 			$6,
 			$7,
 			$8,
-			$9
+			$9,
+			$10,
+			$11,
+			$12,
+			$13,
+			$14,
+			$15,
+			$16,
+			$17,
+			$18,
+			$19
 		) RETURNING id;
 	`,
 		transaction.BookingDate,
@@ -121,46 +322,95 @@ func (s *Service) Create(transaction Transaction) (*Transaction, error) {
 		transaction.Balance,
 		transaction.Amount,
 		categoryID,
+		payeeID,
+		transaction.SEPA.IBAN,
+		transaction.SEPA.BIC,
+		transaction.SEPA.CreditorID,
+		transaction.SEPA.MandateReference,
+		transaction.SEPA.EndToEndReference,
+		transaction.SEPA.CardNumber,
+		transaction.SEPA.CardTerminal,
 		hash,
+		transaction.AccountID,
+		transaction.Manual,
 	).Scan(&id)
 	if err != nil {
 		return nil, err
 	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
 
 	transaction.ID = id
 	return &transaction, nil
 }
 
-func (s *Service) Get(id int64) (*Transaction, error) {
+// transactionColumns are the selected columns of a transaction
+// in the order expected by scanTransaction.
+const transactionColumns = `
+	t.id,
+	t.booking_date,
+	t.valuta_date,
+	t.recipient,
+	t.booking_text,
+	t.purpose,
+	t.balance,
+	t.amount,
+	t.hidden,
+	t.manual,
+	t.note,
+	t.tags,
+	t.account_id,
+	t.iban,
+	t.bic,
+	t.creditor_id,
+	t.mandate_reference,
+	t.end_to_end_reference,
+	t.card_number,
+	t.card_terminal,
+	c.id,
+	c.name,
+	c.description,
+	c.color,
+	p.id,
+	p.name`
+
+// transactionJoins joins the category and payee of a transaction.
+const transactionJoins = `
+	LEFT JOIN categories AS c
+	ON t.category_id = c.id
+	LEFT JOIN payees AS p
+	ON t.payee_id = p.id`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// extendedScanner scans the extra destinations after the columns of a transaction.
+type extendedScanner struct {
+	scanner
+	extra []any
+}
+
+func (s extendedScanner) Scan(dest ...any) error {
+	return s.scanner.Scan(append(dest, s.extra...)...)
+}
+
+func scanTransaction(row scanner) (*Transaction, error) {
 	var (
 		transaction         Transaction
 		recipient           sql.NullString
 		purpose             sql.NullString
+		note                sql.NullString
 		categoryID          sql.NullInt64
 		categoryName        sql.NullString
 		categoryDescription sql.NullString
 		categoryColor       sql.NullString
+		payeeID             sql.NullInt64
+		payeeName           sql.NullString
 	)
-	err := s.db.QueryRow(`
-		SELECT
-			t.id,
-			t.booking_date,
-			t.valuta_date,
-			t.recipient,
-			t.booking_text,
-			t.purpose,
-			t.balance,
-			t.amount,
-			t.hidden,
-			c.id,
-			c.name,
-			c.description,
-			c.color
-		FROM transactions AS t
-			LEFT JOIN categories AS c ON t.category_id = c.id
-		WHERE
-			t.id = $1;
-	`, id).Scan(
+	err := row.Scan(
 		&transaction.ID,
 		&transaction.BookingDate,
 		&transaction.ValutaDate,
@@ -170,10 +420,23 @@ func (s *Service) Get(id int64) (*Transaction, error) {
 		&transaction.Balance,
 		&transaction.Amount,
 		&transaction.Hidden,
+		&transaction.Manual,
+		&note,
+		pq.Array(&transaction.Tags),
+		&transaction.AccountID,
+		&transaction.SEPA.IBAN,
+		&transaction.SEPA.BIC,
+		&transaction.SEPA.CreditorID,
+		&transaction.SEPA.MandateReference,
+		&transaction.SEPA.EndToEndReference,
+		&transaction.SEPA.CardNumber,
+		&transaction.SEPA.CardTerminal,
 		&categoryID,
 		&categoryName,
 		&categoryDescription,
 		&categoryColor,
+		&payeeID,
+		&payeeName,
 	)
 	if err != nil {
 		return nil, err
@@ -185,6 +448,9 @@ func (s *Service) Get(id int64) (*Transaction, error) {
 	if purpose.Valid {
 		transaction.Purpose = &purpose.String
 	}
+	if note.Valid {
+		transaction.Note = &note.String
+	}
 
 	if categoryID.Valid {
 		category := category.Category{
@@ -200,105 +466,111 @@ func (s *Service) Get(id int64) (*Transaction, error) {
 		transaction.Category = &category
 	}
 
+	if payeeID.Valid {
+		transaction.Payee = &payee.Payee{
+			ID:   payeeID.Int64,
+			Name: payeeName.String,
+		}
+	}
+
 	return &transaction, nil
 }
 
-func (s *Service) List(from, to time.Time, orderByDirection OrderByDirection) (*TransactionList, error) {
+func scanTransactions(rows *sql.Rows) ([]Transaction, error) {
+	transactions := make([]Transaction, 0)
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, *transaction)
+	}
+	return transactions, rows.Err()
+}
+
+func (s *Service) Get(id int64) (*Transaction, error) {
+	transaction, err := scanTransaction(s.db.QueryRow(fmt.Sprintf(`
+		SELECT %s
+		FROM transactions AS t %s
+		WHERE
+			t.id = $1;
+	`, transactionColumns, transactionJoins), id))
+	if err == sql.ErrNoRows {
+		return nil, ErrTransactionNotFound
+	}
+	return transaction, err
+}
+
+// List returns the transactions matching the filter in the sort order. The total and the sum cover
+// all matching transactions, even if only a page of them is returned. If more transactions follow
+// the page, the next cursor points to the last transaction of the page.
+func (s *Service) List(filter Filter, sort Sort, page Page) (*TransactionList, error) {
+	if page.Limit < 0 {
+		return nil, fmt.Errorf("%w: negative limit", ErrInvalidFilter)
+	}
+
+	var total queryBuilder
+	total.filter(filter)
+
+	var query queryBuilder
+	query.filter(filter)
+	if page.Cursor != "" {
+		c, err := decodeCursor(page.Cursor, sort)
+		if err != nil {
+			return nil, err
+		}
+		query.after(*c)
+	}
+	limit := "ALL"
+	if page.Limit > 0 {
+		// one more transaction is selected to know, whether another page follows
+		limit = query.arg(page.Limit + 1)
+	}
+	expression, _ := sortExpression(sort.Field)
+
 	rows, err := s.db.Query(fmt.Sprintf(`
-		SELECT
-			t.id,
-			t.booking_date,
-			t.valuta_date,
-			t.recipient,
-			t.booking_text,
-			t.purpose,
-			t.balance,
-			t.amount,
-			t.hidden,
-			c.id,
-			c.name,
-			c.description,
-			c.color
-		FROM transactions AS t
-			LEFT JOIN categories AS c
-			ON t.category_id = c.id
+		SELECT %s, (%s)::text
+		FROM transactions AS t %s
 		WHERE
-			t.booking_date BETWEEN $1 AND $2
-		ORDER BY t.booking_date %s;
-	`, orderByDirection), database.NormalizeTime(from), database.NormalizeTime(to))
+			%s
+		ORDER BY %s
+		LIMIT %s;
+	`, transactionColumns, expression, transactionJoins, query.condition(), orderBy(sort), limit), query.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var transactions []Transaction
+	transactionList := TransactionList{
+		Items: make([]Transaction, 0),
+	}
+	var last cursor
 	for rows.Next() {
-		var (
-			transaction         Transaction
-			recipient           sql.NullString
-			purpose             sql.NullString
-			categoryID          sql.NullInt64
-			categoryName        sql.NullString
-			categoryDescription sql.NullString
-			categoryColor       sql.NullString
-		)
-		err = rows.Scan(
-			&transaction.ID,
-			&transaction.BookingDate,
-			&transaction.ValutaDate,
-			&recipient,
-			&transaction.BookingText,
-			&purpose,
-			&transaction.Balance,
-			&transaction.Amount,
-			&transaction.Hidden,
-			&categoryID,
-			&categoryName,
-			&categoryDescription,
-			&categoryColor,
-		)
-		if err != nil {
-			return nil, err
+		if page.Limit > 0 && len(transactionList.Items) == page.Limit {
+			next := encodeCursor(last)
+			transactionList.NextCursor = &next
+			break
 		}
 
-		if recipient.Valid {
-			transaction.Recipient = &recipient.String
-		}
-		if purpose.Valid {
-			transaction.Purpose = &purpose.String
-		}
-
-		if categoryID.Valid {
-			category := category.Category{
-				ID:   categoryID.Int64,
-				Name: categoryName.String,
-			}
-			if categoryDescription.Valid {
-				category.Description = &categoryDescription.String
-			}
-			if categoryColor.Valid {
-				category.Color = &categoryColor.String
-			}
-			transaction.Category = &category
+		last = cursor{Field: sort.Field, Direction: sort.Direction}
+		transaction, err := scanTransaction(extendedScanner{rows, []any{&last.Value}})
+		if err != nil {
+			return nil, err
 		}
-
-		transactions = append(transactions, transaction)
+		last.ID = transaction.ID
+		transactionList.Items = append(transactionList.Items, *transaction)
 	}
-
-	var transactionList TransactionList
-	transactionList.Items = transactions
-	if len(transactions) == 0 {
-		return &transactionList, nil
+	err = rows.Err()
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.db.QueryRow(`
-		SELECT COUNT(*), SUM(amount)
-		FROM transactions AS t
-			LEFT JOIN categories AS c
-			ON t.category_id = c.id
+	err = s.db.QueryRow(fmt.Sprintf(`
+		SELECT COUNT(*), COALESCE(SUM(t.amount), 0)
+		FROM transactions AS t %s
 		WHERE
-			t.booking_date BETWEEN $1 AND $2;
-	`, database.NormalizeTime(from), database.NormalizeTime(to)).Scan(
+			%s;
+	`, transactionJoins, total.condition()), total.args...).Scan(
 		&transactionList.Total,
 		&transactionList.Sum,
 	)
@@ -309,331 +581,557 @@ func (s *Service) List(from, to time.Time, orderByDirection OrderByDirection) (*
 	return &transactionList, nil
 }
 
-func (s *Service) ListHidden(from, to time.Time, orderByDirection OrderByDirection) (*TransactionList, error) {
-	rows, err := s.db.Query(fmt.Sprintf(`
-		SELECT
-			t.id,
-			t.booking_date,
-			t.valuta_date,
-			t.recipient,
-			t.booking_text,
-			t.purpose,
-			t.balance,
-			t.amount,
-			c.id,
-			c.name,
-			c.description,
-			c.color
-		FROM transactions AS t
-			LEFT JOIN categories AS c
-			ON t.category_id = c.id
+// SumByCategory returns the number and sum of all visible transactions
+// booked between from and to grouped by their category.
+func (s *Service) SumByCategory(from, to time.Time) ([]CategorySum, error) {
+	rows, err := s.db.Query(`
+		SELECT category_id, COUNT(*), SUM(amount)
+		FROM transactions
 		WHERE
-			t.booking_date BETWEEN $1 AND $2
+			booking_date BETWEEN $1 AND $2
 		AND
-			t.hidden = true
-		ORDER BY booking_date %s;
-	`, orderByDirection), database.NormalizeTime(from), database.NormalizeTime(to))
+			hidden = false
+		GROUP BY category_id
+		ORDER BY category_id;
+	`, database.NormalizeTime(from), database.NormalizeTime(to))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	transactions := make([]Transaction, 0)
+	sums := make([]CategorySum, 0)
 	for rows.Next() {
 		var (
-			transaction         Transaction
-			recipient           sql.NullString
-			purpose             sql.NullString
-			categoryID          sql.NullInt64
-			categoryName        sql.NullString
-			categoryDescription sql.NullString
-			categoryColor       sql.NullString
-		)
-		err = rows.Scan(
-			&transaction.ID,
-			&transaction.BookingDate,
-			&transaction.ValutaDate,
-			&recipient,
-			&transaction.BookingText,
-			&purpose,
-			&transaction.Balance,
-			&transaction.Amount,
-			&categoryID,
-			&categoryName,
-			&categoryDescription,
-			&categoryColor,
+			sum        CategorySum
+			categoryID sql.NullInt64
 		)
+		err = rows.Scan(&categoryID, &sum.Count, &sum.Sum)
 		if err != nil {
 			return nil, err
 		}
-
-		if recipient.Valid {
-			transaction.Recipient = &recipient.String
-		}
-		if purpose.Valid {
-			transaction.Purpose = &purpose.String
+		if categoryID.Valid {
+			sum.CategoryID = &categoryID.Int64
 		}
+		sums = append(sums, sum)
+	}
 
-		if categoryID.Valid {
-			category := category.Category{
-				ID:   categoryID.Int64,
-				Name: categoryName.String,
-			}
-			if categoryDescription.Valid {
-				category.Description = &categoryDescription.String
-			}
-			if categoryColor.Valid {
-				category.Color = &categoryColor.String
+	return sums, rows.Err()
+}
+
+func (s *Service) Categorize(actor audit.Actor, id, categoryID int64) error {
+	return s.updateUnlocked(actor, id, `
+		UPDATE transactions
+		SET category_id = $1
+		WHERE id = $2;
+	`, categoryID, id)
+}
+
+func (s *Service) Uncategorize(actor audit.Actor, id int64) error {
+	return s.updateUnlocked(actor, id, `
+		UPDATE transactions
+		SET category_id = NULL
+		WHERE id = $1;
+	`, id)
+}
+
+func (s *Service) Hide(actor audit.Actor, id int64, hide bool) error {
+	return s.updateUnlocked(actor, id, `
+		UPDATE transactions
+		SET hidden = $1
+		WHERE id = $2;
+	`, hide, id)
+}
+
+// updateUnlocked locks the transaction and executes the update within the same database
+// transaction, unless the transaction is booked within a locked period.
+func (s *Service) updateUnlocked(actor audit.Actor, id int64, query string, args ...any) error {
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		SELECT id
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE;
+	`, id)
+	if err != nil {
+		return err
+	}
+	err = s.lockService.WithTx(tx).CheckTransaction(id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// searchText is the indexed text searched for the terms of a search query.
+const searchText = `transaction_search_text(t.recipient, t.booking_text, t.purpose, t.note, t.tags)`
+
+// Search returns the transactions matching the query ordered by their rank for the terms of the
+// query and afterwards from the newest to the oldest. Terms match, if the full-text search of the
+// searchable text matches or it contains the term. At most limit transactions are returned.
+func (s *Service) Search(searchQuery SearchQuery, limit int) (*SearchResult, error) {
+	var query queryBuilder
+	for _, term := range searchQuery.Terms {
+		query.where(
+			"(to_tsvector('german', %[1]s) @@ plainto_tsquery('german', %[2]s) OR %[1]s ILIKE %[3]s)",
+			searchText, query.arg(term), query.arg(likePattern(term)),
+		)
+	}
+	for _, amount := range searchQuery.Amounts {
+		query.where("t.amount %s %s", amount.Operator, query.arg(amount.Value))
+	}
+	if len(searchQuery.Categories) > 0 {
+		categories := make([]string, 0, len(searchQuery.Categories))
+		for _, name := range searchQuery.Categories {
+			if strings.EqualFold(name, uncategorized) {
+				categories = append(categories, "t.category_id IS NULL")
+				continue
 			}
-			transaction.Category = &category
+			categories = append(categories, fmt.Sprintf("LOWER(c.name) = LOWER(%s)", query.arg(name)))
+		}
+		query.where("(%s)", strings.Join(categories, " OR "))
+	}
+	for _, field := range []struct {
+		column string
+		values []string
+	}{
+		{column: "t.recipient", values: searchQuery.Recipients},
+		{column: "t.booking_text", values: searchQuery.BookingTexts},
+		{column: "t.purpose", values: searchQuery.Purposes},
+		{column: "t.note", values: searchQuery.Notes},
+	} {
+		for _, value := range field.values {
+			query.where("%s ILIKE %s", field.column, query.arg(likePattern(value)))
 		}
-
-		transactions = append(transactions, transaction)
 	}
+	query.filter(Filter{
+		From: searchQuery.From,
+		To:   searchQuery.To,
+		Tags: searchQuery.Tags,
+	})
 
-	var transactionList TransactionList
-	transactionList.Items = transactions
-	if len(transactions) == 0 {
-		return &transactionList, nil
+	rank := "0"
+	if len(searchQuery.Terms) > 0 {
+		terms := strings.Join(searchQuery.Terms, " ")
+		rank = fmt.Sprintf(
+			"ts_rank(to_tsvector('german', %[1]s), plainto_tsquery('german', %[2]s)) + word_similarity(%[2]s, %[1]s)",
+			searchText, query.arg(terms),
+		)
 	}
 
-	err = s.db.QueryRow(`
-		SELECT COUNT(*), SUM(amount)
-		FROM transactions AS t
-			LEFT JOIN categories AS c
-			ON t.category_id = c.id
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT %s, %s AS rank, COUNT(*) OVER ()
+		FROM transactions AS t %s
 		WHERE
-			t.booking_date BETWEEN $1 AND $2
-		AND
-			t.hidden = true;
-	`, database.NormalizeTime(from), database.NormalizeTime(to)).Scan(
-		&transactionList.Total,
-		&transactionList.Sum,
-	)
+			%s
+		ORDER BY rank DESC, t.booking_date DESC, t.id ASC
+		LIMIT %s;
+	`, transactionColumns, rank, transactionJoins, query.condition(), query.arg(limit)), query.args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &transactionList, nil
+	result := SearchResult{
+		Items: make([]SearchHit, 0),
+	}
+	for rows.Next() {
+		var hit SearchHit
+		transaction, err := scanTransaction(extendedScanner{rows, []any{&hit.Rank, &result.Total}})
+		if err != nil {
+			return nil, err
+		}
+		hit.Transaction = *transaction
+		result.Items = append(result.Items, hit)
+	}
+	return &result, rows.Err()
 }
 
-func (s *Service) ListByCategoryID(from time.Time, to time.Time, categoryID int64, orderByDirection OrderByDirection) (*TransactionList, error) {
-	rows, err := s.db.Query(fmt.Sprintf(`
-		SELECT
-			t.id,
-			t.booking_date,
-			t.valuta_date,
-			t.recipient,
-			t.booking_text,
-			t.purpose,
-			t.balance,
-			t.amount,
-			c.id,
-			c.name,
-			c.description,
-			c.color
-		FROM transactions AS t
-			INNER JOIN categories AS c
-			ON t.category_id = c.id
+// SetNote replaces the note of the transaction, an empty note removes it. Notes are annotations,
+// so they can be changed for imported transactions and within locked periods.
+func (s *Service) SetNote(actor audit.Actor, id int64, note string) error {
+	var value *string
+	if note = strings.TrimSpace(note); note != "" {
+		value = &note
+	}
+
+	result, err := audit.Exec(s.db, actor, `
+		UPDATE transactions
+		SET note = $1
+		WHERE id = $2;
+	`, value, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTransactionNotFound
+	}
+	return nil
+}
+
+// SetTags replaces the tags of the transaction. Like notes, tags can be changed for
+// imported transactions and within locked periods.
+func (s *Service) SetTags(actor audit.Actor, id int64, tags []string) error {
+	result, err := audit.Exec(s.db, actor, `
+		UPDATE transactions
+		SET tags = $1
+		WHERE id = $2;
+	`, pq.Array(NormalizeTags(tags)), id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTransactionNotFound
+	}
+	return nil
+}
+
+// BulkPatch applies the patch to all transactions matching the filter within one transaction.
+// The filter has to narrow the transactions, so all transactions can't be patched by accident.
+// If any of them is booked within a locked period, their category and visibility aren't changed
+// at all. A dry run returns the transactions, which would be patched, without changing them.
+func (s *Service) BulkPatch(actor audit.Actor, filter Filter, patch TransactionPatchRequest, dryRun bool) (*BulkPatchResult, error) {
+	if !filter.Narrows() {
+		return nil, fmt.Errorf("%w: either IDs or a filter are required", ErrInvalidFilter)
+	}
+
+	var update queryBuilder
+	assignments, err := update.assignments(patch)
+	if err != nil {
+		return nil, err
+	}
+	if patch.CategoryID != nil && *patch.CategoryID != 0 {
+		_, err = s.categoryService.Get(*patch.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var query queryBuilder
+	query.filter(filter)
+	rows, err := tx.Query(fmt.Sprintf(`
+		SELECT t.id
+		FROM transactions AS t %s
 		WHERE
-			t.booking_date BETWEEN $1 AND $2
-		AND
-			t.category_id = $3
-		AND
-			t.hidden = false
-		ORDER BY t.booking_date %s;
-	`, orderByDirection), database.NormalizeTime(from), database.NormalizeTime(to), categoryID)
+			%s
+		ORDER BY t.id
+		FOR UPDATE OF t;
+	`, transactionJoins, query.condition()), query.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	transactions := make([]Transaction, 0)
+	result := BulkPatchResult{
+		IDs:    make([]int64, 0),
+		DryRun: dryRun,
+	}
 	for rows.Next() {
-		var (
-			transaction         Transaction
-			recipient           sql.NullString
-			purpose             sql.NullString
-			category            category.Category
-			categoryDescription sql.NullString
-		)
-		err = rows.Scan(
-			&transaction.ID,
-			&transaction.BookingDate,
-			&transaction.ValutaDate,
-			&recipient,
-			&transaction.BookingText,
-			&purpose,
-			&transaction.Balance,
-			&transaction.Amount,
-			&category.ID,
-			&category.Name,
-			&categoryDescription,
-			&category.Color,
-		)
+		var id int64
+		err = rows.Scan(&id)
 		if err != nil {
 			return nil, err
 		}
+		result.IDs = append(result.IDs, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	result.Affected = len(result.IDs)
 
-		if recipient.Valid {
-			transaction.Recipient = &recipient.String
-		}
-		if purpose.Valid {
-			transaction.Purpose = &purpose.String
+	if patch.changesBooking() && result.Affected > 0 {
+		err = s.lockService.WithTx(tx).CheckTransactions(result.IDs)
+		if err != nil {
+			return nil, err
 		}
+	}
+	if dryRun || result.Affected == 0 {
+		return &result, nil
+	}
 
-		if categoryDescription.Valid {
-			category.Description = &categoryDescription.String
-		}
+	_, err = tx.Exec(fmt.Sprintf(`
+		UPDATE transactions
+		SET %s
+		WHERE id = ANY(%s);
+	`, assignments, update.arg(pq.Array(result.IDs))), update.args...)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
 
-		transaction.Category = &category
-		transactions = append(transactions, transaction)
+// Update replaces the descriptive fields of a manual transaction. Its category, visibility and
+// account are kept. Imported transactions can't be edited.
+func (s *Service) Update(actor audit.Actor, id int64, request TransactionRequest) (*Transaction, error) {
+	current, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !current.Manual {
+		return nil, ErrTransactionImmutable
 	}
 
-	var transactionList TransactionList
-	transactionList.Items = transactions
-	if len(transactions) == 0 {
-		return &transactionList, nil
+	transaction, err := ParseManual(request)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.db.QueryRow(`
-		SELECT COUNT(*), SUM(amount)
-			FROM transactions AS t
-			INNER JOIN categories AS c
-			ON t.category_id = c.id
-		WHERE
-			t.booking_date BETWEEN $1 AND $2
-		AND
-			t.category_id = $3
-		AND
-			t.hidden = false;
-	`, database.NormalizeTime(from), database.NormalizeTime(to), categoryID).Scan(
-		&transactionList.Total,
-		&transactionList.Sum,
+	// neither the old nor the new booking date may be locked
+	locks, err := s.lockService.List()
+	if err != nil {
+		return nil, err
+	}
+	err = lock.Check(locks, current.BookingDate, transaction.BookingDate)
+	if err != nil {
+		return nil, err
+	}
+
+	payees, err := s.payeeService.List()
+	if err != nil {
+		return nil, err
+	}
+	transaction.SEPA = sepa.Parse(transaction.Purpose)
+	transaction.Payee, err = payee.Normalize(payees, transaction.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	var payeeID *int64
+	if transaction.Payee != nil {
+		payeeID = &transaction.Payee.ID
+	}
+
+	hash, err := transaction.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = audit.Exec(s.db, actor, `
+		UPDATE transactions
+		SET
+			booking_date = $1,
+			valuta_date = $2,
+			recipient = $3,
+			booking_text = $4,
+			purpose = $5,
+			amount = $6,
+			payee_id = $7,
+			iban = $8,
+			bic = $9,
+			creditor_id = $10,
+			mandate_reference = $11,
+			end_to_end_reference = $12,
+			card_number = $13,
+			card_terminal = $14,
+			hash = $15
+		WHERE id = $16;
+	`,
+		transaction.BookingDate,
+		transaction.ValutaDate,
+		transaction.Recipient,
+		transaction.BookingText,
+		transaction.Purpose,
+		transaction.Amount,
+		payeeID,
+		transaction.SEPA.IBAN,
+		transaction.SEPA.BIC,
+		transaction.SEPA.CreditorID,
+		transaction.SEPA.MandateReference,
+		transaction.SEPA.EndToEndReference,
+		transaction.SEPA.CardNumber,
+		transaction.SEPA.CardTerminal,
+		hash,
+		id,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &transactionList, nil
+	return s.Get(id)
 }
 
-func (s *Service) Categorize(id, categoryID int64) error {
-	_, err := s.db.Exec(`
-		UPDATE transactions
-		SET category_id = $1
-		WHERE id = $2;
-	`, categoryID, id)
+// Delete deletes a manual transaction, envelope movements assigning it are kept without the
+// reference. Imported transactions can't be deleted, as they are part of the bank statements,
+// transactions with attachments only after their attachments were deleted.
+func (s *Service) Delete(actor audit.Actor, id int64) error {
+	transaction, err := s.Get(id)
 	if err != nil {
 		return err
 	}
-	return nil
-}
+	if !transaction.Manual {
+		return ErrTransactionImmutable
+	}
 
-func (s *Service) Uncategorize(id int64) error {
-	_, err := s.db.Exec(`
-		UPDATE transactions
-		SET category_id = NULL
-		WHERE id = $1;
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		SELECT id
+		FROM transactions
+		WHERE id = $1
+		FOR UPDATE;
 	`, id)
 	if err != nil {
 		return err
 	}
-	return nil
-}
+	err = s.lockService.WithTx(tx).CheckTransaction(id)
+	if err != nil {
+		return err
+	}
 
-func (s *Service) Hide(id int64, hide bool) error {
-	_, err := s.db.Exec(`
-		UPDATE transactions
-		SET hidden = $1
-		WHERE id = $2;
-	`, hide, id)
+	var inUse bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM transaction_attachments
+			WHERE transaction_id = $1
+		);
+	`, id).Scan(&inUse)
 	if err != nil {
 		return err
 	}
-	return nil
+	if inUse {
+		return ErrTransactionInUse
+	}
+
+	_, err = tx.Exec(`
+		UPDATE envelope_movements
+		SET transaction_id = NULL
+		WHERE transaction_id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		DELETE FROM transactions
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTransactionNotFound
+	}
+
+	return tx.Commit()
 }
 
-func (s *Service) ListUnclassified(from time.Time, to time.Time, orderByDirection OrderByDirection) (*TransactionList, error) {
-	rows, err := s.db.Query(fmt.Sprintf(`
-		SELECT
-			id,
-			booking_date,
-			valuta_date,
-			recipient,
-			booking_text,
-			purpose,
-			balance,
-			amount
+// ExtractSEPAFields parses the SEPA fields from the purpose of every transaction
+// again, e.g. for transactions imported before the fields were extracted.
+func (s *Service) ExtractSEPAFields(actor audit.Actor) (*SEPAExtractResult, error) {
+	rows, err := s.db.Query(`
+		SELECT id, purpose
 		FROM transactions
-		WHERE
-			category_id IS NULL
-		AND
-			booking_date BETWEEN $1 AND $2
-		AND
-			hidden = false
-		ORDER BY booking_date %s;
-	`, orderByDirection), database.NormalizeTime(from), database.NormalizeTime(to))
+		WHERE purpose IS NOT NULL;
+	`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	transactions := make([]Transaction, 0)
+	fields := make(map[int64]sepa.Fields)
 	for rows.Next() {
 		var (
-			transaction Transaction
-			recipient   sql.NullString
-			purpose     sql.NullString
-		)
-		err = rows.Scan(
-			&transaction.ID,
-			&transaction.BookingDate,
-			&transaction.ValutaDate,
-			&recipient,
-			&transaction.BookingText,
-			&purpose,
-			&transaction.Balance,
-			&transaction.Amount,
+			id      int64
+			purpose string
 		)
+		err = rows.Scan(&id, &purpose)
 		if err != nil {
 			return nil, err
 		}
+		fields[id] = sepa.Parse(&purpose)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+	rows.Close()
 
-		if recipient.Valid {
-			transaction.Recipient = &recipient.String
-		}
-		if purpose.Valid {
-			transaction.Purpose = &purpose.String
-		}
+	tx, err := audit.Begin(s.db, actor)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-		transactions = append(transactions, transaction)
+	for id, f := range fields {
+		_, err = tx.Exec(`
+			UPDATE transactions
+			SET
+				iban = $1,
+				bic = $2,
+				creditor_id = $3,
+				mandate_reference = $4,
+				end_to_end_reference = $5,
+				card_number = $6,
+				card_terminal = $7
+			WHERE id = $8;
+		`, f.IBAN, f.BIC, f.CreditorID, f.MandateReference, f.EndToEndReference, f.CardNumber, f.CardTerminal, id)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	var transactionList TransactionList
-	transactionList.Items = transactions
-	if len(transactions) == 0 {
-		return &transactionList, nil
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
 	}
+	return &SEPAExtractResult{Evaluated: len(fields)}, nil
+}
 
-	err = s.db.QueryRow(`
-		SELECT COUNT(*), SUM(amount)
+// LatestBalance returns the balance of the account after its most recent imported transaction. Exports
+// list the newest transaction of a day first, so the lowest ID of the latest day is the most recent.
+func (s *Service) LatestBalance(accountID int64) (*Balance, error) {
+	var balance Balance
+	err := s.db.QueryRow(`
+		SELECT booking_date, balance
 		FROM transactions
-		WHERE
-			category_id IS NULL
-		AND
-			booking_date BETWEEN $1 AND $2
-		AND
-			hidden = false;
-	`, database.NormalizeTime(from), database.NormalizeTime(to)).Scan(
-		&transactionList.Total,
-		&transactionList.Sum,
-	)
+		WHERE NOT manual AND account_id = $1
+		ORDER BY booking_date DESC, id ASC
+		LIMIT 1;
+	`, accountID).Scan(&balance.Date, &balance.Balance)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTransactionNotFound
+		}
 		return nil, err
 	}
-
-	return &transactionList, nil
+	return &balance, nil
 }
 
 func (s *Service) Exists(transaction Transaction) (bool, error) {
@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"testing"
+	"time"
 
 	"docqube.de/bookkeeper/pkg/services/category"
 	"docqube.de/bookkeeper/pkg/utils"
@@ -95,3 +96,68 @@ func Test_MatchTransactionCategory(t *testing.T) {
 		})
 	}
 }
+
+func Test_MatchTransactionCategory_RuleHits(t *testing.T) {
+	categoryGroceries := category.Category{
+		ID:   1,
+		Name: "Groceries",
+		Rules: []category.CategoryRule{
+			{ID: 11, Regex: "lidl", MappingField: category.MappingFieldRecipient},
+			{ID: 12, Regex: "kaufumsatz", MappingField: category.MappingFieldPurpose},
+			{ID: 13, Regex: "visa", MappingField: category.MappingFieldRecipient},
+		},
+	}
+	categoryDrugstore := category.Category{
+		ID:   2,
+		Name: "Drugstore",
+		Rules: []category.CategoryRule{
+			{ID: 21, Regex: "kaufumsatz", MappingField: category.MappingFieldPurpose},
+			{ID: 22, Regex: "dm-drogerie", MappingField: category.MappingFieldRecipient},
+		},
+	}
+
+	service := &Service{
+		categories: []category.Category{categoryGroceries, categoryDrugstore},
+	}
+
+	transactions := []Transaction{
+		{
+			BookingDate: time.Date(2023, time.May, 19, 0, 0, 0, 0, time.UTC),
+			Recipient:   utils.NewString("VISA LIDL DIENSTLEISTUNG"),
+			BookingText: "Lastschrift",
+			Purpose:     utils.NewString("NR XXXX 1337 MONSCHAU KAUFUMSATZ 01.01 0815123"),
+		},
+		{
+			BookingDate: time.Date(2023, time.May, 22, 0, 0, 0, 0, time.UTC),
+			Recipient:   utils.NewString("VISA DM-DROGERIE MARKT"),
+			BookingText: "Lastschrift",
+			Purpose:     utils.NewString("NR XXXX 1234 MONSCHAU KAUFUMSATZ 01.01 123456789"),
+		},
+	}
+
+	hits := category.NewRuleHits()
+	for _, transaction := range transactions {
+		_, err := service.matchTransaction(&transaction, hits)
+		assert.NoError(t, err)
+	}
+
+	tests := []struct {
+		ruleID          int64
+		wantHitCount    int64
+		wantShadowed    int64
+		wantLastHitDate *time.Time
+	}{
+		{ruleID: 11, wantHitCount: 1, wantShadowed: 0, wantLastHitDate: &transactions[0].BookingDate},
+		// rules of the same category don't shadow each other
+		{ruleID: 12, wantHitCount: 1, wantShadowed: 0, wantLastHitDate: &transactions[1].BookingDate},
+		{ruleID: 13, wantHitCount: 0, wantShadowed: 0, wantLastHitDate: nil},
+		{ruleID: 21, wantHitCount: 0, wantShadowed: 2, wantLastHitDate: nil},
+		{ruleID: 22, wantHitCount: 0, wantShadowed: 1, wantLastHitDate: nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.wantHitCount, hits.HitCount(tt.ruleID), "hit count of rule %d", tt.ruleID)
+		assert.Equal(t, tt.wantShadowed, hits.ShadowedCount(tt.ruleID), "shadowed count of rule %d", tt.ruleID)
+		assert.Equal(t, tt.wantLastHitDate, hits.LastHitDate(tt.ruleID), "last hit date of rule %d", tt.ruleID)
+	}
+}
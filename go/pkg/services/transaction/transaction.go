@@ -3,14 +3,20 @@ package transaction
 import (
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/payee"
+	"docqube.de/bookkeeper/pkg/services/transaction/sepa"
 )
 
 type Transaction struct {
 	ID          int64              `json:"id"`
+	AccountID   int64              `json:"accountID"`
 	BookingDate time.Time          `json:"bookingDate"`
 	ValutaDate  time.Time          `json:"valutaDate"`
 	Recipient   *string            `json:"recipient"`
@@ -19,18 +25,76 @@ type Transaction struct {
 	Balance     float64            `json:"balance"`
 	Amount      float64            `json:"amount"`
 	Category    *category.Category `json:"category"`
+	Payee       *payee.Payee       `json:"payee"`
+	SEPA        sepa.Fields        `json:"sepa"`
 	Hidden      bool               `json:"hidden"`
+	// Manual transactions are entered by hand instead of being imported from a statement. They
+	// have no bank balance and only their descriptive fields can be edited.
+	Manual bool `json:"manual"`
+	// Note is a free-text annotation, which can be added to imported transactions as well.
+	Note *string `json:"note"`
+	// Tags are lower case labels like "vacation" or "tax", which can be added to imported transactions as well.
+	Tags []string `json:"tags"`
 }
 
 type TransactionList struct {
 	Items []Transaction `json:"items"`
 	Total int64         `json:"total"`
 	Sum   float64       `json:"sum"`
+	// NextCursor selects the following page, it is nil on the last page.
+	NextCursor *string `json:"nextCursor"`
+}
+
+// TransactionRequest contains the descriptive fields of a manual transaction.
+type TransactionRequest struct {
+	BookingDate string   `json:"bookingDate"`
+	ValutaDate  *string  `json:"valutaDate"`
+	Recipient   *string  `json:"recipient"`
+	BookingText string   `json:"bookingText"`
+	Purpose     *string  `json:"purpose"`
+	Amount      *float64 `json:"amount"`
+}
+
+type TransactionCreateRequest struct {
+	TransactionRequest
+	// AccountID defaults to the account statements are imported into.
+	AccountID  *int64 `json:"accountID"`
+	CategoryID *int64 `json:"categoryID"`
 }
 
 type TransactionPatchRequest struct {
+	// CategoryID categorizes the transaction, 0 removes its category.
 	CategoryID *int64 `json:"categoryID"`
 	Hidden     *bool  `json:"hidden"`
+	// Note replaces the note of the transaction, an empty note removes it.
+	Note *string `json:"note"`
+	// Tags replace the tags of the transaction.
+	Tags *[]string `json:"tags"`
+}
+
+type RecategorizeResult struct {
+	Evaluated int `json:"evaluated"`
+	Changed   int `json:"changed"`
+	// Locked is the number of transactions skipped, as they are booked within a locked period.
+	Locked int `json:"locked"`
+}
+
+// CategorySum is the number and sum of all visible transactions of a category.
+// The category ID is nil for unclassified transactions.
+type CategorySum struct {
+	CategoryID *int64  `json:"categoryID"`
+	Count      int64   `json:"count"`
+	Sum        float64 `json:"sum"`
+}
+
+// Balance is the account balance at the end of a day.
+type Balance struct {
+	Date    time.Time `json:"date"`
+	Balance float64   `json:"balance"`
+}
+
+type SEPAExtractResult struct {
+	Evaluated int `json:"evaluated"`
 }
 
 type OrderByDirection string
@@ -40,6 +104,79 @@ const (
 	OrderByDirectionDesc OrderByDirection = "DESC"
 )
 
+var ErrInvalidTransaction = errors.New("invalid transaction")
+
+// manualBookingText is used for manual transactions entered without a booking text.
+const manualBookingText = "Manuelle Buchung"
+
+// ParseManual validates the request and returns the manual transaction described by it.
+// The valuta date defaults to the booking date.
+func ParseManual(request TransactionRequest) (*Transaction, error) {
+	bookingDate, err := time.Parse(time.DateOnly, request.BookingDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTransaction, err)
+	}
+	valutaDate := bookingDate
+	if request.ValutaDate != nil {
+		valutaDate, err = time.Parse(time.DateOnly, *request.ValutaDate)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTransaction, err)
+		}
+	}
+
+	if request.Amount == nil || *request.Amount == 0 || math.IsNaN(*request.Amount) || math.IsInf(*request.Amount, 0) {
+		return nil, fmt.Errorf("%w: amount missing", ErrInvalidTransaction)
+	}
+
+	recipient := trimmed(request.Recipient)
+	purpose := trimmed(request.Purpose)
+	bookingText := strings.TrimSpace(request.BookingText)
+	if recipient == nil && purpose == nil && bookingText == "" {
+		return nil, fmt.Errorf("%w: recipient, booking text or purpose required", ErrInvalidTransaction)
+	}
+	if bookingText == "" {
+		bookingText = manualBookingText
+	}
+
+	return &Transaction{
+		BookingDate: bookingDate,
+		ValutaDate:  valutaDate,
+		Recipient:   recipient,
+		BookingText: bookingText,
+		Purpose:     purpose,
+		Amount:      *request.Amount,
+		Manual:      true,
+	}, nil
+}
+
+// NormalizeTags returns the tags in lower case without surrounding whitespace,
+// empty tags and duplicates, ordered like they were passed.
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// trimmed returns the value without surrounding whitespace or nil, if it is empty.
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	result := strings.TrimSpace(*value)
+	if result == "" {
+		return nil
+	}
+	return &result
+}
+
 func (t *Transaction) Hash() (string, error) {
 	buffer := struct {
 		BookingDate time.Time `json:"bookingDate"`
@@ -49,6 +186,8 @@ func (t *Transaction) Hash() (string, error) {
 		Purpose     *string   `json:"purpose"`
 		Balance     float64   `json:"balance"`
 		Amount      float64   `json:"amount"`
+		// omitted for imported transactions, so their hashes stay stable
+		Manual bool `json:"manual,omitempty"`
 	}{
 		BookingDate: t.BookingDate,
 		ValutaDate:  t.ValutaDate,
@@ -57,6 +196,7 @@ func (t *Transaction) Hash() (string, error) {
 		Purpose:     t.Purpose,
 		Balance:     t.Balance,
 		Amount:      t.Amount,
+		Manual:      t.Manual,
 	}
 	data, err := json.Marshal(buffer)
 	if err != nil {
@@ -67,26 +207,7 @@ func (t *Transaction) Hash() (string, error) {
 
 func (t *Transaction) MatchesCategory(c *category.Category) (bool, error) {
 	for _, rule := range c.Rules {
-		var (
-			matches bool
-			err     error
-		)
-
-		switch rule.MappingField {
-		case category.MappingFieldRecipient:
-			if t.Recipient != nil {
-				matches, err = rule.Match(*t.Recipient)
-			}
-
-		case category.MappingFieldBookingText:
-			matches, err = rule.Match(t.BookingText)
-
-		case category.MappingFieldPurpose:
-			if t.Purpose != nil {
-				matches, err = rule.Match(*t.Purpose)
-			}
-		}
-
+		matches, err := t.MatchesRule(&rule)
 		if err != nil {
 			return false, err
 		}
@@ -97,3 +218,37 @@ func (t *Transaction) MatchesCategory(c *category.Category) (bool, error) {
 
 	return false, nil
 }
+
+func (t *Transaction) MatchesRule(rule *category.CategoryRule) (bool, error) {
+	switch rule.MappingField {
+	case category.MappingFieldRecipient:
+		if t.Recipient != nil {
+			return rule.Match(*t.Recipient)
+		}
+
+	case category.MappingFieldBookingText:
+		return rule.Match(t.BookingText)
+
+	case category.MappingFieldPurpose:
+		if t.Purpose != nil {
+			return rule.Match(*t.Purpose)
+		}
+
+	case category.MappingFieldPayee:
+		if t.Payee != nil {
+			return rule.Match(t.Payee.Name)
+		}
+
+	case category.MappingFieldIBAN:
+		if t.SEPA.IBAN != nil {
+			return rule.MatchExact(*t.SEPA.IBAN), nil
+		}
+
+	case category.MappingFieldCreditorID:
+		if t.SEPA.CreditorID != nil {
+			return rule.MatchExact(*t.SEPA.CreditorID), nil
+		}
+	}
+
+	return false, nil
+}
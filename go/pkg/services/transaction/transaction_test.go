@@ -2,8 +2,11 @@ package transaction
 
 import (
 	"testing"
+	"time"
 
 	"docqube.de/bookkeeper/pkg/services/category"
+	"docqube.de/bookkeeper/pkg/services/payee"
+	"docqube.de/bookkeeper/pkg/services/transaction/sepa"
 	"docqube.de/bookkeeper/pkg/utils"
 	"github.com/stretchr/testify/assert"
 )
@@ -36,6 +39,26 @@ func Test_Category_MatchesTransaction(t *testing.T) {
 		},
 	}
 
+	categoryMobile := category.Category{
+		Name: "Mobile",
+		Rules: []category.CategoryRule{
+			{
+				Regex:        "^telekom$",
+				MappingField: category.MappingFieldPayee,
+			},
+		},
+	}
+
+	categoryRent := category.Category{
+		Name: "Rent",
+		Rules: []category.CategoryRule{
+			{
+				Regex:        "de89 3704 0044 0532 0130 00",
+				MappingField: category.MappingFieldIBAN,
+			},
+		},
+	}
+
 	tests := []struct {
 		name        string
 		category    *category.Category
@@ -98,6 +121,49 @@ func Test_Category_MatchesTransaction(t *testing.T) {
 			want:    false,
 			wantErr: false,
 		},
+		{
+			name:     "should match payee",
+			category: &categoryMobile,
+			transaction: Transaction{
+				Recipient:   utils.NewString("Telekom Deutschland GmbH"),
+				BookingText: "Lastschrift",
+				Payee:       &payee.Payee{ID: 1, Name: "Telekom"},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:     "should not match without payee",
+			category: &categoryMobile,
+			transaction: Transaction{
+				Recipient:   utils.NewString("Telekom"),
+				BookingText: "Lastschrift",
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name:     "should match iban exactly",
+			category: &categoryRent,
+			transaction: Transaction{
+				Recipient:   utils.NewString("Hausverwaltung"),
+				BookingText: "Dauerauftrag",
+				SEPA:        sepa.Fields{IBAN: utils.NewString("DE89370400440532013000")},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:     "should not match iban prefix",
+			category: &categoryRent,
+			transaction: Transaction{
+				Recipient:   utils.NewString("Hausverwaltung"),
+				BookingText: "Dauerauftrag",
+				SEPA:        sepa.Fields{IBAN: utils.NewString("DE8937040044053201300012")},
+			},
+			want:    false,
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -113,3 +179,80 @@ func Test_Category_MatchesTransaction(t *testing.T) {
 		})
 	}
 }
+
+func Test_ParseManual(t *testing.T) {
+	tests := []struct {
+		name    string
+		request TransactionRequest
+		want    *Transaction
+		wantErr error
+	}{
+		{
+			name:    "should default the valuta date and booking text",
+			request: TransactionRequest{BookingDate: "2024-05-03", Recipient: utils.NewString(" Bäcker "), Amount: utils.NewFloat64(-4.5)},
+			want: &Transaction{
+				BookingDate: time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC),
+				ValutaDate:  time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC),
+				Recipient:   utils.NewString("Bäcker"),
+				BookingText: manualBookingText,
+				Amount:      -4.5,
+				Manual:      true,
+			},
+		},
+		{
+			name:    "should keep the passed valuta date and booking text",
+			request: TransactionRequest{BookingDate: "2024-05-03", ValutaDate: utils.NewString("2024-05-02"), BookingText: "Erstattung", Purpose: utils.NewString(""), Amount: utils.NewFloat64(20)},
+			want: &Transaction{
+				BookingDate: time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC),
+				ValutaDate:  time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+				BookingText: "Erstattung",
+				Amount:      20,
+				Manual:      true,
+			},
+		},
+		{
+			name:    "should reject invalid booking dates",
+			request: TransactionRequest{BookingDate: "03.05.2024", BookingText: "Bargeld", Amount: utils.NewFloat64(-10)},
+			wantErr: ErrInvalidTransaction,
+		},
+		{
+			name:    "should reject missing amounts",
+			request: TransactionRequest{BookingDate: "2024-05-03", BookingText: "Bargeld", Amount: utils.NewFloat64(0)},
+			wantErr: ErrInvalidTransaction,
+		},
+		{
+			name:    "should reject transactions without any description",
+			request: TransactionRequest{BookingDate: "2024-05-03", Recipient: utils.NewString(" "), Amount: utils.NewFloat64(-10)},
+			wantErr: ErrInvalidTransaction,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseManual(tt.request)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_Transaction_Hash_Manual(t *testing.T) {
+	imported := Transaction{BookingDate: time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC), BookingText: "Bargeld", Amount: -10}
+	manual := imported
+	manual.Manual = true
+
+	importedHash, err := imported.Hash()
+	assert.NoError(t, err)
+	manualHash, err := manual.Hash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, importedHash, manualHash)
+}
+
+func Test_NormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"vacation", "tax"}, NormalizeTags([]string{" Vacation", "tax", "", "vacation "}))
+	assert.Equal(t, []string{}, NormalizeTags(nil))
+}
@@ -0,0 +1,5 @@
+package utils
+
+func NewFloat64(f float64) *float64 {
+	return &f
+}